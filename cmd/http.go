@@ -2,16 +2,22 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/gateway"
 
 	"github.com/spf13/cobra"
 )
 
+var migrateOnStart bool
+
 var httpCmd = &cobra.Command{
 	Use:   "http",
 	Short: "A brief description of your command",
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("http called")
+		if migrateOnStart {
+			config.AppConfig.DBConfig.AutoMigrate = true
+		}
 		if err := gateway.Server(); err != nil {
 			panic(err)
 		}
@@ -19,5 +25,6 @@ var httpCmd = &cobra.Command{
 }
 
 func init() {
+	httpCmd.Flags().BoolVar(&migrateOnStart, "migrate", false, "apply pending migrations before starting, like DB_AUTO_MIGRATE=true")
 	rootCmd.AddCommand(httpCmd)
 }
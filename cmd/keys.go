@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"github.com/saleh-ghazimoradi/GoJobs/utils"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	keyAlgorithm string
+	keyBits      int
+	keyDryRun    bool
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage the keys that sign access tokens",
+	Long: `Manage the keys in JWT_KEYS_DIR that sign access tokens.
+
+Every key in the directory is published at /.well-known/jwks.json and accepted
+for verification. A new key starts signing JWT_KEY_ACTIVATION_DELAY after it
+was created, and running servers re-read the directory every
+JWT_KEYS_RELOAD_INTERVAL, so keys can be rotated without a restart.`,
+}
+
+var keysGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a new signing key",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := generateKey()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("generated %s key %s in %s\n", key.Algorithm, key.ID, key.Path)
+		return nil
+	},
+}
+
+var keysRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Generate a new signing key and delete retired ones",
+	Long: `Generate a new signing key and delete the keys that stopped signing longer
+ago than the lifetime of any token they signed. Run it on a schedule shorter
+than the intended key lifetime; old keys stay published until it is safe to
+remove them.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := config.AppConfig.JWT
+		now := time.Now()
+
+		keys, err := utils.ReadSigningKeys(cfg.KeysDir)
+		if err != nil {
+			return err
+		}
+		retired := utils.RetiredSigningKeys(keys, now)
+		for _, key := range retired {
+			if keyDryRun {
+				fmt.Printf("would delete retired key %s\n", key.ID)
+				continue
+			}
+			if err = os.Remove(key.Path); err != nil {
+				return err
+			}
+			fmt.Printf("deleted retired key %s\n", key.ID)
+		}
+
+		// The first key signs straight away.
+		signingFrom := now.Add(cfg.ActivationDelay).UTC().Format(time.RFC3339)
+		if len(keys) == len(retired) {
+			signingFrom = "now"
+		}
+		if keyDryRun {
+			fmt.Printf("would generate a new %s key, signing from %s\n", keyAlgorithm, signingFrom)
+			return nil
+		}
+		key, err := generateKey()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("generated %s key %s, signing from %s\n", key.Algorithm, key.ID, signingFrom)
+		return nil
+	},
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the signing keys and what they are used for",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keys, err := utils.ReadSigningKeys(config.AppConfig.JWT.KeysDir)
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			fmt.Println("no keys, generate one with `GoJobs keys generate`")
+			return nil
+		}
+
+		now := time.Now()
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "KID\tALGORITHM\tCREATED\tSTATUS")
+		for _, key := range keys {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", key.ID, key.Algorithm, key.CreatedAt.UTC().Format(time.RFC3339), utils.SigningKeyStatus(keys, key, now))
+		}
+		return tw.Flush()
+	},
+}
+
+func generateKey() (*utils.SigningKey, error) {
+	key, err := utils.GenerateSigningKey(keyAlgorithm, keyBits)
+	if err != nil {
+		return nil, err
+	}
+	if err = utils.WriteSigningKey(config.AppConfig.JWT.KeysDir, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func init() {
+	for _, c := range []*cobra.Command{keysGenerateCmd, keysRotateCmd} {
+		c.Flags().StringVar(&keyAlgorithm, "alg", utils.AlgorithmEdDSA, "key algorithm, EdDSA or RS256")
+		c.Flags().IntVar(&keyBits, "bits", 3072, "RSA key size, only used with RS256")
+	}
+	keysRotateCmd.Flags().BoolVar(&keyDryRun, "dry-run", false, "print what would change without changing it")
+
+	keysCmd.AddCommand(keysGenerateCmd, keysRotateCmd, keysListCmd)
+	rootCmd.AddCommand(keysCmd)
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/scripts/migrations"
+	"github.com/saleh-ghazimoradi/GoJobs/utils"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply or roll back the embedded database migrations",
+	Long: `Apply or roll back the database migrations embedded in the binary.
+
+Migrations run under a Postgres advisory lock, so concurrent runs wait for each
+other. Versions are stored in schema_migrations, as with the migrate CLI.`,
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up [n]",
+	Short: "Apply all pending migrations, or the next n",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := migrationCount(args, 0)
+		if err != nil {
+			return err
+		}
+		return withMigrator(func(migrator *migrations.Migrator) error {
+			applied, err := migrator.Up(n)
+			if err != nil {
+				return err
+			}
+			if !applied {
+				fmt.Println("no pending migrations")
+			}
+			return printVersion(migrator)
+		})
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down [n]",
+	Short: "Roll back the last migration, or the last n",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := migrationCount(args, 1)
+		if err != nil {
+			return err
+		}
+		return withMigrator(func(migrator *migrations.Migrator) error {
+			rolledBack, err := migrator.Down(n)
+			if err != nil {
+				return err
+			}
+			if !rolledBack {
+				fmt.Println("no migrations to roll back")
+			}
+			return printVersion(migrator)
+		})
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the schema version and pending migrations",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMigrator(func(migrator *migrations.Migrator) error {
+			status, err := migrator.Status()
+			if err != nil {
+				return err
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+			for _, m := range status.Migrations {
+				state := "pending"
+				switch {
+				case m.Version == status.Version && status.Dirty:
+					state = "dirty"
+				case m.Applied:
+					state = "applied"
+				}
+				fmt.Fprintf(tw, "%06d\t%s\t%s\n", m.Version, m.Name, state)
+			}
+			if err = tw.Flush(); err != nil {
+				return err
+			}
+			return printVersion(migrator)
+		})
+	},
+}
+
+var migrateForceCmd = &cobra.Command{
+	Use:   "force <version>",
+	Short: "Set the schema version and clear the dirty flag without running migrations",
+	Long: `Set the schema version and clear the dirty flag without running migrations.
+
+Use it after a migration failed halfway and the database was repaired by hand.
+Version -1 means no migration has been applied.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version, err := strconv.Atoi(args[0])
+		if err != nil || version < -1 {
+			return fmt.Errorf("invalid version %q", args[0])
+		}
+		return withMigrator(func(migrator *migrations.Migrator) error {
+			if err := migrator.Force(version); err != nil {
+				return err
+			}
+			return printVersion(migrator)
+		})
+	},
+}
+
+// withMigrator connects to the primary database and runs fn with a migrator.
+func withMigrator(fn func(migrator *migrations.Migrator) error) error {
+	db, err := utils.PostConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := migrations.New(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	return fn(migrator)
+}
+
+func migrationCount(args []string, fallback int) (int, error) {
+	if len(args) == 0 {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid number of migrations %q", args[0])
+	}
+	return n, nil
+}
+
+func printVersion(migrator *migrations.Migrator) error {
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		fmt.Printf("schema version %d (dirty, fix the database and run `GoJobs migrate force %d`)\n", status.Version, status.Version)
+		return nil
+	}
+	fmt.Printf("schema version %d\n", status.Version)
+	return nil
+}
+
+func init() {
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd, migrateForceCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"github.com/saleh-ghazimoradi/GoJobs/utils"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	seedOptions service_models.SeedOptions
+	seedPurge   bool
+)
+
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Fill the database with generated users, companies and jobs",
+	Long: `Fill the database with generated users, companies and jobs for local
+development and load tests. Rows are copied in with COPY, one transaction per
+batch.
+
+The same --seed always generates the same data, with timestamps relative to the
+time of the run. Generated users get email addresses and generated companies
+get websites under ` + service_models.SeedDomain + `. Every generated user signs in with
+the password "` + service_models.SeedPassword + `".
+
+Generated rows are marked as seeded. --purge deletes exactly those, the jobs the
+generated users posted and the rows that cascade from them, such as
+applications to those jobs.`,
+	Example: `  GoJobs seed --users 1000 --companies 100 --jobs 20000 --seed 42
+  GoJobs seed --purge`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := utils.PostConnection()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		seedService := service.NewSeedService(repository.NewSeedRepository(db, db), repository.NewTagRepository(db, db),
+			service.NewTransactor(db), config.AppConfig.RBAC.DefaultRoles)
+		ctx := context.Background()
+		start := time.Now()
+
+		if seedPurge {
+			result, err := seedService.Purge(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("deleted %d users, %d companies and %d jobs in %s\n", result.Users, result.Companies, result.Jobs, time.Since(start).Round(time.Millisecond))
+			return nil
+		}
+
+		result, err := seedService.Generate(ctx, &seedOptions)
+		if result != nil {
+			fmt.Printf("inserted %d users, %d companies and %d jobs in %s\n", result.Users, result.Companies, result.Jobs, time.Since(start).Round(time.Millisecond))
+		}
+		return err
+	},
+}
+
+func init() {
+	seedCmd.Flags().IntVar(&seedOptions.Users, "users", 100, "number of users to generate")
+	seedCmd.Flags().IntVar(&seedOptions.Companies, "companies", 20, "number of companies to generate")
+	seedCmd.Flags().IntVar(&seedOptions.Jobs, "jobs", 500, "number of jobs to generate")
+	seedCmd.Flags().Uint64Var(&seedOptions.Seed, "seed", 1, "random seed; the same seed generates the same data")
+	seedCmd.Flags().IntVar(&seedOptions.BatchSize, "batch", 1000, "rows inserted per transaction")
+	seedCmd.Flags().BoolVar(&seedPurge, "purge", false, "delete the generated data instead of generating more")
+	seedCmd.MarkFlagsMutuallyExclusive("purge", "users")
+	seedCmd.MarkFlagsMutuallyExclusive("purge", "companies")
+	seedCmd.MarkFlagsMutuallyExclusive("purge", "jobs")
+	seedCmd.MarkFlagsMutuallyExclusive("purge", "seed")
+
+	rootCmd.AddCommand(seedCmd)
+}
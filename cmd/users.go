@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/gateway"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"github.com/saleh-ghazimoradi/GoJobs/mailer"
+	"github.com/saleh-ghazimoradi/GoJobs/utils"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	userUsername string
+	userEmail    string
+	userAdmin    bool
+	userRole     string
+	userYes      bool
+)
+
+var usersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "Manage user accounts and admins",
+	Long: `Manage user accounts directly against the database, for example to create the
+first admin or to recover an admin who is locked out.
+
+Passwords are never taken from flags. They are prompted for on a terminal, or
+read from the first line of stdin when it is not one:
+
+  printf '%s\n' "$PASSWORD" | GoJobs users reset-password alice`,
+}
+
+var usersCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a user, optionally with the admin role",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		password, err := readPassword("Password: ", true)
+		if err != nil {
+			return err
+		}
+		payload := service_models.UserPayload{Username: userUsername, Email: userEmail, Password: password}
+		if err = gateway.Validate.Struct(payload); err != nil {
+			return err
+		}
+
+		return withUserServices(func(ctx context.Context, s *userServices) error {
+			var user *service_models.User
+			err := s.transactor.WithTx(ctx, func(tx *sql.Tx) error {
+				// RegisterUser replaces the password with its hash, so a retry
+				// needs a fresh user.
+				user = &service_models.User{Username: payload.Username, Email: payload.Email, Password: payload.Password}
+				if err := s.auth.GetWithTXT(tx).RegisterUser(ctx, user); err != nil {
+					return err
+				}
+				if !userAdmin {
+					return nil
+				}
+				roles, err := s.roles.GetWithTXT(tx).SetUserRoles(ctx, user.ID, append(slices.Clone(user.Roles), service_models.RoleAdmin))
+				if err != nil {
+					return err
+				}
+				user.Roles = roles.Roles
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("created user %s (id %d) with roles %s\n", user.Username, user.ID, strings.Join(user.Roles, ", "))
+			return nil
+		})
+	},
+}
+
+var usersPromoteCmd = &cobra.Command{
+	Use:   "promote <username|id>",
+	Short: "Give a user the admin role, or the role given with --role",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeUserRoles(args[0], func(roles []string) []string {
+			if slices.Contains(roles, userRole) {
+				return roles
+			}
+			return append(roles, userRole)
+		})
+	},
+}
+
+var usersDemoteCmd = &cobra.Command{
+	Use:   "demote <username|id>",
+	Short: "Take the admin role, or the role given with --role, from a user",
+	Long: `Take the admin role, or the role given with --role, from a user.
+
+The last admin cannot be demoted.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeUserRoles(args[0], func(roles []string) []string {
+			return slices.DeleteFunc(roles, func(role string) bool { return role == userRole })
+		})
+	},
+}
+
+var usersResetPasswordCmd = &cobra.Command{
+	Use:   "reset-password <username|id>",
+	Short: "Set a new password for a user",
+	Long: `Set a new password for a user without the current one.
+
+Every session of the user is ended and any login lockout is lifted.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withUserServices(func(ctx context.Context, s *userServices) error {
+			user, err := s.findUser(ctx, args[0])
+			if err != nil {
+				return err
+			}
+
+			password, err := readPassword("New password for "+user.Username+": ", true)
+			if err != nil {
+				return err
+			}
+			if err = gateway.Validate.Var(password, "required,min=3,max=72"); err != nil {
+				return fmt.Errorf("invalid password: %w", err)
+			}
+
+			err = s.transactor.WithTx(ctx, func(tx *sql.Tx) error {
+				return s.auth.GetWithTXT(tx).SetPassword(ctx, user.ID, password)
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("password of %s changed, all sessions ended\n", user.Username)
+			return nil
+		})
+	},
+}
+
+var usersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List users and their roles",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withUserServices(func(ctx context.Context, s *userServices) error {
+			users, err := s.users.GetAllUsers(ctx)
+			if err != nil {
+				return err
+			}
+			slices.SortFunc(users, func(a, b *service_models.User) int {
+				return cmp.Compare(a.ID, b.ID)
+			})
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tUSERNAME\tEMAIL\tROLES\tVERIFIED\tMFA\tCREATED")
+			for _, user := range users {
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%t\t%t\t%s\n", user.ID, user.Username, user.Email, strings.Join(user.Roles, ","), user.EmailVerifiedAt != nil, user.MFAEnabled, user.CreateAt.UTC().Format(time.RFC3339))
+			}
+			return tw.Flush()
+		})
+	},
+}
+
+var usersDeleteCmd = &cobra.Command{
+	Use:   "delete <username|id>",
+	Short: "Delete a user and everything they own",
+	Long: `Delete a user and everything they own.
+
+The last admin cannot be deleted.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withUserServices(func(ctx context.Context, s *userServices) error {
+			user, err := s.findUser(ctx, args[0])
+			if err != nil {
+				return err
+			}
+
+			if !userYes {
+				if err = confirm(fmt.Sprintf("Delete user %s (id %d)? Type the username to confirm: ", user.Username, user.ID), user.Username); err != nil {
+					return err
+				}
+			}
+
+			if err = s.users.DeleteUser(ctx, user.ID); err != nil {
+				return err
+			}
+			fmt.Printf("deleted user %s\n", user.Username)
+			return nil
+		})
+	},
+}
+
+type userServices struct {
+	transactor service.Transactor
+	users      service.User
+	auth       service.Authenticate
+	roles      service.Role
+}
+
+// withUserServices connects to the primary database and runs fn with the
+// same services the API uses. The commands send no email.
+func withUserServices(fn func(ctx context.Context, s *userServices) error) error {
+	db, err := utils.PostConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	userDB := repository.NewUserRepository(db, db)
+	roleDB := repository.NewRoleRepository(db, db)
+	mfaService := service.NewMFAService(repository.NewMFARepository(db, db), userDB)
+	transactor := service.NewTransactor(db)
+	s := &userServices{
+		transactor: transactor,
+		users:      service.NewUserService(userDB, roleDB, transactor),
+		auth: service.NewAuthenticateService(userDB, repository.NewRefreshTokenRepository(db, db), repository.NewPasswordResetRepository(db, db),
+			repository.NewLoginRepository(db, db), repository.NewSecurityEventRepository(db, db), mfaService, mailer.NewLogMailer()),
+		roles: service.NewRoleService(roleDB),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	return fn(ctx, s)
+}
+
+// findUser looks a user up by ID when ref is a number and by username
+// otherwise.
+func (s *userServices) findUser(ctx context.Context, ref string) (*service_models.User, error) {
+	var (
+		user *service_models.User
+		err  error
+	)
+	if id, parseErr := strconv.ParseInt(ref, 10, 64); parseErr == nil {
+		user, err = s.users.GetUserById(ctx, id)
+	} else {
+		user, err = s.users.GetUserByUsername(ctx, ref)
+	}
+	if errors.Is(err, repository.ErrRecordNotFound) {
+		return nil, fmt.Errorf("user %q not found", ref)
+	}
+	return user, err
+}
+
+// changeUserRoles applies change to the user's roles in a transaction, so the
+// last-admin check cannot race with another change.
+func changeUserRoles(ref string, change func(roles []string) []string) error {
+	return withUserServices(func(ctx context.Context, s *userServices) error {
+		user, err := s.findUser(ctx, ref)
+		if err != nil {
+			return err
+		}
+
+		var updated *service_models.UserRoles
+		err = s.transactor.WithTx(ctx, func(tx *sql.Tx) error {
+			roles := s.roles.GetWithTXT(tx)
+			current, err := roles.GetUserRoles(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			updated, err = roles.SetUserRoles(ctx, user.ID, change(slices.Clone(current.Roles)))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s now has roles: %s\n", user.Username, strings.Join(updated.Roles, ", "))
+		return nil
+	})
+}
+
+// readPassword prompts for a password without echo on a terminal, twice when
+// confirm is set. Otherwise it reads the first line of stdin.
+func readPassword(prompt string, confirm bool) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		password := strings.TrimRight(line, "\r\n")
+		if password == "" {
+			return "", errors.New("no password on stdin")
+		}
+		return password, nil
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat password: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if string(again) != string(password) {
+			return "", errors.New("passwords do not match")
+		}
+	}
+	return string(password), nil
+}
+
+// confirm asks the user to type expected on a terminal. Without a terminal
+// the command has to be run with --yes.
+func confirm(prompt, expected string) error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.New("stdin is not a terminal, pass --yes to confirm")
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if strings.TrimSpace(answer) != expected {
+		return errors.New("aborted")
+	}
+	return nil
+}
+
+func init() {
+	usersCreateCmd.Flags().StringVar(&userUsername, "username", "", "username of the new user")
+	usersCreateCmd.Flags().StringVar(&userEmail, "email", "", "email address of the new user")
+	usersCreateCmd.Flags().BoolVar(&userAdmin, "admin", false, "also give the user the admin role")
+	_ = usersCreateCmd.MarkFlagRequired("username")
+	_ = usersCreateCmd.MarkFlagRequired("email")
+
+	for _, c := range []*cobra.Command{usersPromoteCmd, usersDemoteCmd} {
+		c.Flags().StringVar(&userRole, "role", service_models.RoleAdmin, "role to give or take")
+	}
+	usersDeleteCmd.Flags().BoolVarP(&userYes, "yes", "y", false, "delete without asking for confirmation")
+
+	usersCmd.AddCommand(usersCreateCmd, usersPromoteCmd, usersDemoteCmd, usersResetPasswordCmd, usersListCmd, usersDeleteCmd)
+	rootCmd.AddCommand(usersCmd)
+}
@@ -14,16 +14,159 @@ type Config struct {
 	DBConfig     DBConfig
 	JWT          JWT
 	UploadDIR    UploadDIR
+	Scheduler    Scheduler
+	Reset        PasswordReset
+	Mailer       Mailer
+	Verification EmailVerification
+	MFA          MFA
+	RBAC         RBAC
+	RateLimit    RateLimit
+	Lockout      LoginLockout
+	APIKeys      APIKeys
+	OIDC         OIDC
 }
 
+// JWT configures access tokens. They are signed with the asymmetric keys in
+// KeysDir, which are all published at /.well-known/jwks.json and accepted for
+// verification. A new key only starts signing ActivationDelay after it was
+// created, so that every instance and every JWKS consumer knows it by then;
+// instances re-read the directory every ReloadInterval. SigningKeyID pins the
+// signing key instead. HS256 with SecretKEY is only used when it is listed in
+// Algorithms and KeysDir holds no keys.
 type JWT struct {
-	SecretKEY string `env:"JWT_SECRET"`
+	SecretKEY       string        `env:"JWT_SECRET"`
+	AccessTokenTTL  time.Duration `env:"JWT_ACCESS_TOKEN_TTL" envDefault:"15m"`
+	RefreshTokenTTL time.Duration `env:"JWT_REFRESH_TOKEN_TTL" envDefault:"720h"`
+	Issuer          string        `env:"JWT_ISSUER" envDefault:"gojobs"`
+	Audience        string        `env:"JWT_AUDIENCE" envDefault:"gojobs-api"`
+	Algorithms      []string      `env:"JWT_ALGORITHMS" envSeparator:"," envDefault:"EdDSA,RS256"`
+	KeysDir         string        `env:"JWT_KEYS_DIR" envDefault:"keys/jwt"`
+	SigningKeyID    string        `env:"JWT_SIGNING_KEY_ID"`
+	ActivationDelay time.Duration `env:"JWT_KEY_ACTIVATION_DELAY" envDefault:"10m"`
+	ReloadInterval  time.Duration `env:"JWT_KEYS_RELOAD_INTERVAL" envDefault:"1m"`
 }
 
 type UploadDIR struct {
 	Upload string `env:"UPLOAD_DIR"`
 }
 
+type Scheduler struct {
+	JobSweepInterval time.Duration `env:"JOB_SWEEP_INTERVAL" envDefault:"5m"`
+	JobRetention     time.Duration `env:"JOB_ARCHIVE_RETENTION" envDefault:"720h"`
+}
+
+type PasswordReset struct {
+	TokenTTL time.Duration `env:"PASSWORD_RESET_TOKEN_TTL" envDefault:"30m"`
+	// URL is the frontend page that receives the reset token as ?token=.
+	URL string `env:"PASSWORD_RESET_URL" envDefault:"http://localhost:3000/reset-password"`
+}
+
+// EmailVerification configures the signed links sent to confirm email
+// addresses. Secret falls back to the JWT secret when empty.
+type EmailVerification struct {
+	Secret          string        `env:"EMAIL_VERIFICATION_SECRET"`
+	TokenTTL        time.Duration `env:"EMAIL_VERIFICATION_TOKEN_TTL" envDefault:"48h"`
+	URL             string        `env:"EMAIL_VERIFICATION_URL" envDefault:"http://localhost:8080/v1/verify-email"`
+	ResendInterval  time.Duration `env:"EMAIL_VERIFICATION_RESEND_INTERVAL" envDefault:"2m"`
+	RequiredToPost  bool          `env:"EMAIL_VERIFICATION_REQUIRED_TO_POST" envDefault:"false"`
+	RequiredToApply bool          `env:"EMAIL_VERIFICATION_REQUIRED_TO_APPLY" envDefault:"false"`
+}
+
+// MFA configures two-factor authentication. EncryptionKey protects the stored
+// TOTP secrets and falls back to the JWT secret when empty.
+type MFA struct {
+	Issuer        string        `env:"MFA_ISSUER" envDefault:"GoJobs"`
+	EncryptionKey string        `env:"MFA_ENCRYPTION_KEY"`
+	ChallengeTTL  time.Duration `env:"MFA_CHALLENGE_TTL" envDefault:"5m"`
+	MaxAttempts   int           `env:"MFA_MAX_ATTEMPTS" envDefault:"5"`
+	LockoutPeriod time.Duration `env:"MFA_LOCKOUT_PERIOD" envDefault:"15m"`
+}
+
+// RBAC configures role-based access control. DefaultRoles are given to every
+// newly registered user; posting jobs takes the recruiter role, which an admin
+// grants.
+type RBAC struct {
+	DefaultRoles []string `env:"RBAC_DEFAULT_ROLES" envSeparator:"," envDefault:"candidate"`
+}
+
+// LoginLockout configures brute-force protection on login. An account is
+// locked after MaxAttempts failures within Window, for LockoutBase doubled on
+// each consecutive lockout up to LockoutMax. An IP address is refused once it
+// reaches IPMaxAttempts failures within Window. UnlockURL is the frontend page
+// that receives the unlock token as ?token=.
+type LoginLockout struct {
+	MaxAttempts    int           `env:"LOGIN_MAX_ATTEMPTS" envDefault:"5"`
+	IPMaxAttempts  int           `env:"LOGIN_IP_MAX_ATTEMPTS" envDefault:"50"`
+	Window         time.Duration `env:"LOGIN_FAILURE_WINDOW" envDefault:"15m"`
+	LockoutBase    time.Duration `env:"LOGIN_LOCKOUT_BASE" envDefault:"5m"`
+	LockoutMax     time.Duration `env:"LOGIN_LOCKOUT_MAX" envDefault:"24h"`
+	UnlockTokenTTL time.Duration `env:"LOGIN_UNLOCK_TOKEN_TTL" envDefault:"24h"`
+	UnlockURL      string        `env:"LOGIN_UNLOCK_URL" envDefault:"http://localhost:3000/unlock-account"`
+}
+
+// OIDC configures sign-in with an OpenID Connect provider using the
+// authorization code flow with PKCE. It is off unless Issuer and ClientID are
+// set. CookieKey encrypts the cookie that carries the flow state and falls
+// back to the JWT secret when empty. AutoCreateUsers creates an account on the
+// first sign-in, and LinkByEmail links an identity to the existing user with
+// the same email address when the provider says it is verified.
+type OIDC struct {
+	Issuer          string        `env:"OIDC_ISSUER"`
+	ClientID        string        `env:"OIDC_CLIENT_ID"`
+	ClientSecret    string        `env:"OIDC_CLIENT_SECRET"`
+	RedirectURL     string        `env:"OIDC_REDIRECT_URL" envDefault:"http://localhost:8080/v1/oidc/callback"`
+	Scopes          []string      `env:"OIDC_SCOPES" envSeparator:"," envDefault:"openid,email,profile"`
+	StateTTL        time.Duration `env:"OIDC_STATE_TTL" envDefault:"10m"`
+	CookieKey       string        `env:"OIDC_COOKIE_KEY"`
+	CookieSecure    bool          `env:"OIDC_COOKIE_SECURE" envDefault:"true"`
+	AutoCreateUsers bool          `env:"OIDC_AUTO_CREATE_USERS" envDefault:"true"`
+	LinkByEmail     bool          `env:"OIDC_LINK_BY_EMAIL" envDefault:"false"`
+}
+
+// APIKeys limits how many active keys each user can hold.
+type APIKeys struct {
+	MaxPerUser int `env:"API_KEY_MAX_PER_USER" envDefault:"25"`
+}
+
+// RateLimit configures the token buckets that limit each client. Global
+// applies to every request by client IP, Auth to the login, registration and
+// password endpoints by client IP, and User to authenticated routes by user
+// ID. A group refills Requests tokens every Period and holds up to Burst of
+// them; zero Requests turns the group off. TrustedProxyHops is the number of
+// proxies in front of the API that append to X-Forwarded-For; the client IP is
+// read from the entry the outermost of them appended. Leave it at zero when
+// clients connect directly.
+type RateLimit struct {
+	Enabled          bool          `env:"RATE_LIMIT_ENABLED" envDefault:"true"`
+	Store            string        `env:"RATE_LIMIT_STORE" envDefault:"memory"`
+	TrustedProxyHops int           `env:"RATE_LIMIT_TRUSTED_PROXY_HOPS" envDefault:"0"`
+	SweepInterval    time.Duration `env:"RATE_LIMIT_SWEEP_INTERVAL" envDefault:"1m"`
+	GlobalRequests   int           `env:"RATE_LIMIT_GLOBAL_REQUESTS" envDefault:"600"`
+	GlobalPeriod     time.Duration `env:"RATE_LIMIT_GLOBAL_PERIOD" envDefault:"1m"`
+	GlobalBurst      int           `env:"RATE_LIMIT_GLOBAL_BURST" envDefault:"100"`
+	AuthRequests     int           `env:"RATE_LIMIT_AUTH_REQUESTS" envDefault:"10"`
+	AuthPeriod       time.Duration `env:"RATE_LIMIT_AUTH_PERIOD" envDefault:"1m"`
+	AuthBurst        int           `env:"RATE_LIMIT_AUTH_BURST" envDefault:"5"`
+	UserRequests     int           `env:"RATE_LIMIT_USER_REQUESTS" envDefault:"300"`
+	UserPeriod       time.Duration `env:"RATE_LIMIT_USER_PERIOD" envDefault:"1m"`
+	UserBurst        int           `env:"RATE_LIMIT_USER_BURST" envDefault:"60"`
+}
+
+// Mailer selects how outbound email is delivered. Transport is one of smtp,
+// file or log.
+type Mailer struct {
+	Transport    string        `env:"MAIL_TRANSPORT" envDefault:"log"`
+	Sender       string        `env:"MAIL_SENDER" envDefault:"GoJobs <no-reply@gojobs.local>"`
+	FileDir      string        `env:"MAIL_FILE_DIR" envDefault:"./tmp/mail"`
+	QueueSize    int           `env:"MAIL_QUEUE_SIZE" envDefault:"100"`
+	Workers      int           `env:"MAIL_WORKERS" envDefault:"2"`
+	SendTimeout  time.Duration `env:"MAIL_SEND_TIMEOUT" envDefault:"30s"`
+	SMTPHost     string        `env:"SMTP_HOST" envDefault:"localhost"`
+	SMTPPort     int           `env:"SMTP_PORT" envDefault:"1025"`
+	SMTPUsername string        `env:"SMTP_USERNAME"`
+	SMTPPassword string        `env:"SMTP_PASSWORD"`
+}
+
 type ServerConfig struct {
 	Port         string        `env:"SERVER_PORT,required"`
 	Version      string        `env:"SERVER_VERSION,required"`
@@ -43,6 +186,14 @@ type DBConfig struct {
 	MaxIdleConns int           `env:"DB_MAX_IDLE_CONNECTIONS,required"`
 	MaxIdleTime  time.Duration `env:"DB_MAX_IDLE_TIME,required"`
 	Timeout      time.Duration `env:"DB_TIMEOUT,required"`
+	// ReplicaSource is the DSN of an optional read replica. Reads go to it
+	// while it lags at most ReplicaMaxLag behind, checked every
+	// ReplicaCheckInterval, and to the primary otherwise.
+	ReplicaSource        string        `env:"DB_REPLICA_SOURCE"`
+	ReplicaMaxLag        time.Duration `env:"DB_REPLICA_MAX_LAG" envDefault:"10s"`
+	ReplicaCheckInterval time.Duration `env:"DB_REPLICA_CHECK_INTERVAL" envDefault:"5s"`
+	// AutoMigrate applies pending migrations when the HTTP server starts.
+	AutoMigrate bool `env:"DB_AUTO_MIGRATE" envDefault:"false"`
 }
 
 func LoadingConfig() error {
@@ -85,6 +236,66 @@ func LoadingConfig() error {
 	}
 	config.UploadDIR = *uploadDirConfig
 
+	schedulerConfig := &Scheduler{}
+	if err := env.Parse(schedulerConfig); err != nil {
+		log.Fatalf("unable to parse config: %v", err)
+	}
+	config.Scheduler = *schedulerConfig
+
+	resetConfig := &PasswordReset{}
+	if err := env.Parse(resetConfig); err != nil {
+		log.Fatalf("unable to parse config: %v", err)
+	}
+	config.Reset = *resetConfig
+
+	mailerConfig := &Mailer{}
+	if err := env.Parse(mailerConfig); err != nil {
+		log.Fatalf("unable to parse config: %v", err)
+	}
+	config.Mailer = *mailerConfig
+
+	verificationConfig := &EmailVerification{}
+	if err := env.Parse(verificationConfig); err != nil {
+		log.Fatalf("unable to parse config: %v", err)
+	}
+	config.Verification = *verificationConfig
+
+	mfaConfig := &MFA{}
+	if err := env.Parse(mfaConfig); err != nil {
+		log.Fatalf("unable to parse config: %v", err)
+	}
+	config.MFA = *mfaConfig
+
+	rbacConfig := &RBAC{}
+	if err := env.Parse(rbacConfig); err != nil {
+		log.Fatalf("unable to parse config: %v", err)
+	}
+	config.RBAC = *rbacConfig
+
+	rateLimitConfig := &RateLimit{}
+	if err := env.Parse(rateLimitConfig); err != nil {
+		log.Fatalf("unable to parse config: %v", err)
+	}
+	config.RateLimit = *rateLimitConfig
+
+	lockoutConfig := &LoginLockout{}
+	if err := env.Parse(lockoutConfig); err != nil {
+		log.Fatalf("unable to parse config: %v", err)
+	}
+	config.Lockout = *lockoutConfig
+
+	apiKeysConfig := &APIKeys{}
+	if err := env.Parse(apiKeysConfig); err != nil {
+		log.Fatalf("unable to parse config: %v", err)
+	}
+	config.APIKeys = *apiKeysConfig
+
+	oidcConfig := &OIDC{}
+	if err := env.Parse(oidcConfig); err != nil {
+		log.Fatalf("unable to parse config: %v", err)
+	}
+	config.OIDC = *oidcConfig
+
 	AppConfig = config
 
 	return nil
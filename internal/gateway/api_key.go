@@ -0,0 +1,143 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"net/http"
+	"time"
+)
+
+type apiKey struct {
+	apiKeyService service.APIKey
+}
+
+// CreateAPIKeyHandler creates an API key for the authenticated user.
+// @Summary Create an API key
+// @Description Creates a named API key for integrations. Scopes are permission names and each must be one the user has; the key acts with the user's current permissions limited to its scopes. The key is returned only in this response. Send it in the X-API-Key header or as "Authorization: Bearer <key>". API keys cannot manage accounts, companies, roles or other API keys.
+// @Tags API Keys
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param APIKeyPayload body service_models.APIKeyPayload true "Name, scopes and optional expiry"
+// @Success 201 {object} service_models.CreatedAPIKey "API key with its plaintext key"
+// @Failure 400 {object} ErrorResponse "Bad Request, invalid scope or expiry"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 409 {object} ErrorResponse "Too many API keys"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/api-keys [post]
+func (a *apiKey) CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var payload service_models.APIKeyPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err := Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	granted, _ := r.Context().Value("permissions").([]string)
+
+	key, err := a.apiKeyService.CreateAPIKey(ctx, &service_models.APIKey{
+		UserID:    userID,
+		Name:      payload.Name,
+		Scopes:    payload.Scopes,
+		ExpiresAt: payload.ExpiresAt,
+	}, granted)
+	if err != nil {
+		apiKeyErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusCreated, key); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// GetAllAPIKeysHandler lists the API keys of the authenticated user.
+// @Summary List API keys
+// @Description Lists the API keys of the authenticated user, revoked and expired ones included. Keys are identified by their prefix; the full key is never shown again.
+// @Tags API Keys
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} service_models.APIKey "API keys"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/api-keys [get]
+func (a *apiKey) GetAllAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userID := r.Context().Value("userID").(int64)
+
+	keys, err := a.apiKeyService.GetAllAPIKeys(ctx, userID)
+	if err != nil {
+		internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, keys); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// RevokeAPIKeyHandler revokes an API key of the authenticated user.
+// @Summary Revoke an API key
+// @Description Revokes an API key of the authenticated user. It stops working immediately.
+// @Tags API Keys
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int64 true "API key ID"
+// @Success 200 {string} string "The API key was successfully revoked"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "API key not found or already revoked"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/api-keys/{id} [delete]
+func (a *apiKey) RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+
+	if err = a.apiKeyService.RevokeAPIKey(ctx, id, userID); err != nil {
+		apiKeyErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, "the API key was successfully revoked"); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+func apiKeyErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrRecordNotFound):
+		notFoundResponse(w, r, err)
+	case errors.Is(err, repository.ErrInvalidScope), errors.Is(err, repository.ErrInvalidExpiry):
+		badRequestResponse(w, r, err)
+	case errors.Is(err, repository.ErrTooManyAPIKeys):
+		conflictResponse(w, r, err)
+	default:
+		internalServerError(w, r, err)
+	}
+}
+
+func NewAPIKeyHandler(apiKeyService service.APIKey) *apiKey {
+	return &apiKey{
+		apiKeyService: apiKeyService,
+	}
+}
@@ -0,0 +1,414 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"github.com/saleh-ghazimoradi/GoJobs/utils"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var allowedResumeExtensions = map[string]bool{
+	".pdf":  true,
+	".doc":  true,
+	".docx": true,
+	".odt":  true,
+	".txt":  true,
+}
+
+type application struct {
+	applicationService service.Application
+}
+
+// ApplyToJobHandler submits an application to a job listing.
+// @Summary Apply to a job
+// @Description Submits an application with a cover letter and an optional resume file to a job listing. A user can apply only once to the same job.
+// @Tags Applications
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Job ID"
+// @Param cover_letter formData string true "Cover letter"
+// @Param resume formData file false "Resume file (pdf, doc, docx, odt or txt)"
+// @Success 201 {object} service_models.Application "Application submitted"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Job not found"
+// @Failure 409 {object} ErrorResponse "Already applied"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/jobs/{id}/applications [post]
+func (a *application) ApplyToJobHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	jobID, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+
+	if err = r.ParseMultipartForm(10 << 20); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	payload := service_models.ApplicationPayload{CoverLetter: r.FormValue("cover_letter")}
+	if err = Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	app := &service_models.Application{
+		JobID:       jobID,
+		UserID:      userID,
+		CoverLetter: payload.CoverLetter,
+	}
+
+	file, header, err := r.FormFile("resume")
+	switch {
+	case errors.Is(err, http.ErrMissingFile):
+	case err != nil:
+		badRequestResponse(w, r, err)
+		return
+	default:
+		defer file.Close()
+
+		ext := strings.ToLower(filepath.Ext(header.Filename))
+		if !allowedResumeExtensions[ext] {
+			badRequestResponse(w, r, fmt.Errorf("unsupported resume file type %q", ext))
+			return
+		}
+
+		// Check the application before storing the resume, so rejected
+		// applications leave no file behind.
+		if err = a.applicationService.CheckApplication(ctx, app); err != nil {
+			applyErrorResponse(w, r, err)
+			return
+		}
+
+		filename := fmt.Sprintf("resume-%d-%d-%d%s", jobID, userID, time.Now().UnixNano(), ext)
+		if err = saveResume(filename, file); err != nil {
+			internalServerError(w, r, err)
+			return
+		}
+		app.Resume = &filename
+	}
+
+	createdApplication, err := a.applicationService.ApplyToJob(ctx, app)
+	if err != nil {
+		if app.Resume != nil {
+			_ = utils.DeleteFileExist(filepath.Join(config.AppConfig.UploadDIR.Upload, *app.Resume))
+		}
+		applyErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusCreated, createdApplication); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// saveResume writes a resume to the upload directory. A partly written file is
+// removed.
+func saveResume(filename string, file io.Reader) error {
+	uploadDir := config.AppConfig.UploadDIR.Upload
+	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	resumePath := filepath.Join(uploadDir, filename)
+	saveFile, err := os.Create(resumePath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(saveFile, file)
+	if closeErr := saveFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = utils.DeleteFileExist(resumePath)
+		return err
+	}
+	return nil
+}
+
+func applyErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrRecordNotFound):
+		notFoundResponse(w, r, err)
+	case errors.Is(err, repository.ErrDuplicateApplication):
+		conflictResponse(w, r, err)
+	case errors.Is(err, repository.ErrOwnJobApplication), errors.Is(err, repository.ErrJobNotOpen):
+		badRequestResponse(w, r, err)
+	default:
+		internalServerError(w, r, err)
+	}
+}
+
+// GetAllApplicationsByJobHandler lists the applications submitted to a job listing.
+// @Summary List applications for a job
+// @Description Lists every application submitted to a job listing. Only the owner of the job, the members of its company or a user with the applications:manage permission can see them.
+// @Tags Applications
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Job ID"
+// @Success 200 {array} service_models.Application "Applications for the job"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Job not found"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/jobs/{id}/applications [get]
+func (a *application) GetAllApplicationsByJobHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	jobID, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	canManageAll := hasPermission(r, service_models.PermissionApplicationsManage)
+
+	applications, err := a.applicationService.GetAllApplicationsByJobID(ctx, jobID, userID, canManageAll)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			notFoundResponse(w, r, err)
+		case errors.Is(err, repository.ErrUnAuthorized):
+			forbiddenResponse(w, r)
+		default:
+			internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, applications); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// GetMyApplicationsHandler lists the applications of the authenticated user.
+// @Summary List my applications
+// @Description Lists every application the authenticated user has submitted.
+// @Tags Applications
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} service_models.Application "Applications of the authenticated user"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/applications [get]
+func (a *application) GetMyApplicationsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	userID := r.Context().Value("userID").(int64)
+
+	applications, err := a.applicationService.GetAllApplicationsByUserID(ctx, userID)
+	if err != nil {
+		internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, applications); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// GetApplicationByIdHandler retrieves a single application.
+// @Summary Retrieve an application by ID
+// @Description Fetches an application. It is visible to the applicant, the owner of the job, the members of its company and users with the applications:manage permission.
+// @Tags Applications
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Application ID"
+// @Success 200 {object} service_models.Application "Application details"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Application not found"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/applications/{id} [get]
+func (a *application) GetApplicationByIdHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	app, ok := a.readApplication(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	if err := jsonResponse(w, http.StatusOK, app); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// GetApplicationResumeHandler downloads the resume attached to an application.
+// @Summary Download an application's resume
+// @Description Returns the resume file attached to an application. It is available to the applicant, the owner of the job, the members of its company and users with the applications:manage permission.
+// @Tags Applications
+// @Produce octet-stream
+// @Security ApiKeyAuth
+// @Param id path int true "Application ID"
+// @Success 200 {file} file "Resume file"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Application or resume not found"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/applications/{id}/resume [get]
+func (a *application) GetApplicationResumeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	app, ok := a.readApplication(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	if app.Resume == nil {
+		notFoundResponse(w, r, fmt.Errorf("application %d has no resume", app.ID))
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", *app.Resume))
+	http.ServeFile(w, r, filepath.Join(config.AppConfig.UploadDIR.Upload, *app.Resume))
+}
+
+// ChangeStageHandler moves an application to another stage of the hiring pipeline.
+// @Summary Change an application's stage
+// @Description Moves an application through the pipeline applied, screening, interview, offer, hired or rejected. Only valid transitions are accepted and every change is recorded in the application's history. Only the owner of the job, the members of its company or a user with the applications:manage permission can change the stage.
+// @Tags Applications
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Application ID"
+// @Param ChangeStagePayload body service_models.ChangeStagePayload true "New stage and an optional note"
+// @Success 200 {object} service_models.Application "Updated application"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Application not found"
+// @Failure 409 {object} ErrorResponse "Edit conflict"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/applications/{id}/stage [patch]
+func (a *application) ChangeStageHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	var payload service_models.ChangeStagePayload
+	if err = readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err = Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	canManageAll := hasPermission(r, service_models.PermissionApplicationsManage)
+
+	app, err := a.applicationService.ChangeStage(ctx, id, payload.Stage, payload.Note, userID, canManageAll)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			notFoundResponse(w, r, err)
+		case errors.Is(err, repository.ErrUnAuthorized):
+			forbiddenResponse(w, r)
+		case errors.Is(err, repository.ErrInvalidStage):
+			badRequestResponse(w, r, err)
+		case errors.Is(err, repository.ErrEditConflict):
+			conflictResponse(w, r, err)
+		default:
+			internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, app); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// GetApplicationHistoryHandler lists the stage changes of an application.
+// @Summary Retrieve an application's history
+// @Description Lists every stage change of an application with its actor, timestamp and note. It is visible to the applicant, the owner of the job, the members of its company and users with the applications:manage permission.
+// @Tags Applications
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Application ID"
+// @Success 200 {array} service_models.ApplicationHistory "Stage history, oldest first"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Application not found"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/applications/{id}/history [get]
+func (a *application) GetApplicationHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	canManageAll := hasPermission(r, service_models.PermissionApplicationsManage)
+
+	history, err := a.applicationService.GetApplicationHistory(ctx, id, userID, canManageAll)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			notFoundResponse(w, r, err)
+		case errors.Is(err, repository.ErrUnAuthorized):
+			forbiddenResponse(w, r)
+		default:
+			internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, history); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+func (a *application) readApplication(ctx context.Context, w http.ResponseWriter, r *http.Request) (*service_models.Application, bool) {
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return nil, false
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	canManageAll := hasPermission(r, service_models.PermissionApplicationsManage)
+
+	app, err := a.applicationService.GetApplicationById(ctx, id, userID, canManageAll)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			notFoundResponse(w, r, err)
+		case errors.Is(err, repository.ErrUnAuthorized):
+			forbiddenResponse(w, r)
+		default:
+			internalServerError(w, r, err)
+		}
+		return nil, false
+	}
+	return app, true
+}
+
+func NewApplicationHandler(applicationService service.Application) *application {
+	return &application{
+		applicationService: applicationService,
+	}
+}
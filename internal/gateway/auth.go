@@ -2,29 +2,38 @@ package gateway
 
 import (
 	"context"
+	"errors"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"github.com/saleh-ghazimoradi/GoJobs/logger"
 	"net/http"
+	"strconv"
 	"time"
 )
 
 type authenticate struct {
-	authService service.Authenticate
+	authService         service.Authenticate
+	verificationService service.EmailVerification
 }
 
-// loginHandler handles user login and returns a token.
+// loginHandler handles user login and returns a token pair.
 // @Summary User login
-// @Description Authenticates a user and returns a JWT token if the credentials are valid.
+// @Description Authenticates a user and returns a short-lived JWT access token and a refresh token if the credentials are valid. Use POST /v1/token/refresh to get a new access token. Accounts with two-factor authentication get mfa_required and an mfa_token instead, to be completed with POST /v1/login/mfa. After too many failed attempts the account is locked for a growing period and its owner is emailed an unlock link; too many failures from one IP address are refused as well.
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param LoginAuthPayload body service_models.LoginAuthPayload true "Login credentials"
-// @Success 200 {string} string "JWT token"
+// @Param Accept-Language header string false "Preferred language of the lockout email"
+// @Success 200 {object} service_models.LoginResult "Access and refresh tokens, or a two-factor challenge"
 // @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 401 {object} ErrorResponse "Invalid username or password"
+// @Failure 429 {object} ErrorResponse "Too many failed attempts or rate limit exceeded"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /v1/login [post]
 func (a *authenticate) loginHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
 	defer cancel()
 	var loginAuthPayload service_models.LoginAuthPayload
 	if err := readJSON(w, r, &loginAuthPayload); err != nil {
@@ -36,32 +45,161 @@ func (a *authenticate) loginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := a.authService.LoginUser(ctx, loginAuthPayload.Username, loginAuthPayload.Password)
+	tokens, err := a.authService.LoginUser(ctx, loginAuthPayload.Username, loginAuthPayload.Password, clientIP(r), r.Header.Get("Accept-Language"))
 	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInvalidCredentials):
+			unauthorizedErrorResponse(w, r, err)
+		case errors.Is(err, repository.ErrLoginLocked):
+			tooManyRequestsResponse(w, r, err)
+		default:
+			internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, tokens); err != nil {
+		internalServerError(w, r, err)
+		return
+	}
+
+}
+
+// loginMFAHandler completes a two-factor login.
+// @Summary Complete two-factor login
+// @Description Exchanges the mfa_token returned by POST /v1/login and a TOTP code or a recovery code for an access token and a refresh token.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param MFALoginPayload body service_models.MFALoginPayload true "Challenge token and code"
+// @Success 200 {object} service_models.TokenPair "Access and refresh tokens"
+// @Failure 400 {object} ErrorResponse "Bad Request or invalid code"
+// @Failure 401 {object} ErrorResponse "Invalid or expired challenge"
+// @Failure 429 {object} ErrorResponse "Too many failed codes or rate limit exceeded"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/login/mfa [post]
+func (a *authenticate) loginMFAHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+	var payload service_models.MFALoginPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err := Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	tokens, err := a.authService.CompleteMFALogin(ctx, payload.MFAToken, payload.Code)
+	if err != nil {
+		mfaErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, tokens); err != nil {
 		internalServerError(w, r, err)
+	}
+}
+
+// refreshTokenHandler rotates a refresh token.
+// @Summary Refresh the access token
+// @Description Exchanges a refresh token for a new access token and a new refresh token. Each refresh token can be used once; reusing one revokes every token of its login session.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param RefreshTokenPayload body service_models.RefreshTokenPayload true "Refresh token"
+// @Success 200 {object} service_models.TokenPair "New access and refresh tokens"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 401 {object} ErrorResponse "Invalid, expired or reused refresh token"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/token/refresh [post]
+func (a *authenticate) refreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+	var payload service_models.RefreshTokenPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err := Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
 		return
 	}
 
-	if err = jsonResponse(w, http.StatusOK, token); err != nil {
+	tokens, err := a.authService.RefreshToken(ctx, payload.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInvalidRefreshToken):
+			unauthorizedErrorResponse(w, r, err)
+		default:
+			internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, tokens); err != nil {
 		internalServerError(w, r, err)
+	}
+}
+
+// logoutHandler revokes a login session.
+// @Summary Log out
+// @Description Revokes the refresh token and every token rotated from the same login. With all_devices set, every session of the user is ended and access tokens issued so far stop working.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param LogoutPayload body service_models.LogoutPayload true "Refresh token of the session"
+// @Success 200 {string} string "Logged out"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 401 {object} ErrorResponse "Unknown refresh token"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/logout [post]
+func (a *authenticate) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+	var payload service_models.LogoutPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err := Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
 		return
 	}
 
+	if err := a.authService.Logout(ctx, payload.RefreshToken, payload.AllDevices); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInvalidRefreshToken):
+			unauthorizedErrorResponse(w, r, err)
+		default:
+			internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := jsonResponse(w, http.StatusOK, "logged out"); err != nil {
+		internalServerError(w, r, err)
+	}
 }
 
 // registerHandler handles user registration by creating a new user.
 // @Summary User registration
-// @Description Registers a new user with the provided username, password, and email.
+// @Description Registers a new user with the provided username, password, and email, and emails a link to verify the address.
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param RegisterAuthPayload body service_models.RegisterAuthPayload true "User registration credentials"
+// @Param Accept-Language header string false "Preferred language of the verification email"
 // @Success 201 {object} service_models.User "User created"
 // @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /v1/register [post]
 func (a *authenticate) registerHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
 	defer cancel()
 	var registerAuthPayload service_models.RegisterAuthPayload
 	if err := readJSON(w, r, &registerAuthPayload); err != nil {
@@ -85,27 +223,30 @@ func (a *authenticate) registerHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The account exists at this point; a failed email can be resent later.
+	if err := a.verificationService.SendVerification(ctx, us, r.Header.Get("Accept-Language")); err != nil {
+		logger.Logger.Error("failed to send verification email", "user_id", us.ID, "error", err.Error())
+	}
+
 	if err := jsonResponse(w, http.StatusCreated, us); err != nil {
 		internalServerError(w, r, err)
 		return
 	}
 }
 
-// ForgotPasswordHandler handles the password reset request by sending a password to the user.
+// ForgotPasswordHandler starts a password reset.
 // @Summary Password reset request
-// @Description Requests a password reset for the provided username and returns a password if successful.
+// @Description Emails a single-use, time-limited password reset link to the user. The response is the same whether or not the username exists.
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param ForgotPasswordRequest body service_models.ForgotPasswordRequest true "User's username for password reset"
-// @Success 200 {string} string "Password reset successful"
+// @Param Accept-Language header string false "Preferred language of the email"
+// @Success 202 {string} string "Reset email sent if the account exists"
 // @Failure 400 {object} ErrorResponse "Bad Request"
-// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
 // @Router /v1/forgotpassword [post]
 func (a *authenticate) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
-	defer cancel()
-
 	var passReq service_models.ForgotPasswordRequest
 	if err := readJSON(w, r, &passReq); err != nil {
 		badRequestResponse(w, r, err)
@@ -116,18 +257,181 @@ func (a *authenticate) ForgotPasswordHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	password, err := a.authService.ForgotPassword(ctx, passReq.Username)
-	if err != nil {
+	locale := r.Header.Get("Accept-Language")
+
+	// The lookup and the email run in the background so that the response
+	// time does not reveal whether the account exists. They outlive the
+	// request, so its context must not cancel them.
+	ctx := context.WithoutCancel(r.Context())
+	background(func() {
+		ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+		defer cancel()
+		if err := a.authService.ForgotPassword(ctx, passReq.Username, locale); err != nil {
+			logger.Logger.Error("password reset request failed", "error", err.Error())
+		}
+	})
+
+	if err := jsonResponse(w, http.StatusAccepted, "if the account exists, a password reset link has been sent to its email address"); err != nil {
 		internalServerError(w, r, err)
+	}
+}
+
+// ResetPasswordHandler sets a new password with a reset token.
+// @Summary Reset password
+// @Description Sets a new password using the token from the password reset email. The token works once, every session of the user is ended and any login lockout is lifted.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param ResetPasswordPayload body service_models.ResetPasswordPayload true "Reset token and new password"
+// @Success 200 {string} string "Password reset"
+// @Failure 400 {object} ErrorResponse "Bad Request or invalid token"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/resetpassword [post]
+func (a *authenticate) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	var payload service_models.ResetPasswordPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err := Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
 		return
 	}
 
-	if err = jsonResponse(w, http.StatusOK, password); err != nil {
+	if err := a.authService.ResetPassword(ctx, payload.Token, payload.Password); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInvalidResetToken):
+			badRequestResponse(w, r, err)
+		default:
+			internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := jsonResponse(w, http.StatusOK, "your password has been reset, please log in again"); err != nil {
 		internalServerError(w, r, err)
+	}
+}
+
+// UnlockAccountHandler lifts a login lockout.
+// @Summary Unlock account
+// @Description Lifts the login lockout of an account using the token from the lockout email. The token works once.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param UnlockAccountPayload body service_models.UnlockAccountPayload true "Unlock token"
+// @Success 200 {string} string "Account unlocked"
+// @Failure 400 {object} ErrorResponse "Bad Request or invalid token"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/unlock-account [post]
+func (a *authenticate) UnlockAccountHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	var payload service_models.UnlockAccountPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
 		return
 	}
+	if err := Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := a.authService.UnlockAccount(ctx, payload.Token); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInvalidUnlockToken):
+			badRequestResponse(w, r, err)
+		default:
+			internalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := jsonResponse(w, http.StatusOK, "your account has been unlocked, you can log in again"); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// VerifyEmailHandler verifies an email address.
+// @Summary Verify email address
+// @Description Marks the user's email address as verified using the token from the verification email. The token stops working if the email is changed.
+// @Tags Authentication
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {string} string "Email verified"
+// @Failure 400 {object} ErrorResponse "Invalid or expired token"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/verify-email [get]
+func (a *authenticate) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	token := r.URL.Query().Get("token")
+	if token == "" || len(token) > 256 {
+		badRequestResponse(w, r, repository.ErrInvalidVerifyToken)
+		return
+	}
+
+	if err := a.verificationService.VerifyEmail(ctx, token); err != nil {
+		verificationErrorResponse(w, r, err)
+		return
+	}
+
+	if err := jsonResponse(w, http.StatusOK, "your email address has been verified"); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// ResendVerificationHandler sends a new verification email.
+// @Summary Resend verification email
+// @Description Emails a new verification link to the authenticated user. Limited to one email per resend interval.
+// @Tags Authentication
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Accept-Language header string false "Preferred language of the email"
+// @Success 202 {string} string "Verification email sent"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 409 {object} ErrorResponse "Email already verified"
+// @Failure 429 {object} ErrorResponse "Sent too recently"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/verify-email/resend [post]
+func (a *authenticate) ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
+	defer cancel()
+
+	userID := r.Context().Value("userID").(int64)
+	if err := a.verificationService.ResendVerification(ctx, userID, r.Header.Get("Accept-Language")); err != nil {
+		verificationErrorResponse(w, r, err)
+		return
+	}
+
+	if err := jsonResponse(w, http.StatusAccepted, "a verification link has been sent to your email address"); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+func verificationErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrInvalidVerifyToken):
+		badRequestResponse(w, r, err)
+	case errors.Is(err, repository.ErrEmailAlreadyVerified):
+		conflictResponse(w, r, err)
+	case errors.Is(err, repository.ErrVerificationLimited):
+		rateLimitExceededResponse(w, r, strconv.Itoa(int(config.AppConfig.Verification.ResendInterval.Seconds())))
+	case errors.Is(err, repository.ErrRecordNotFound):
+		notFoundResponse(w, r, err)
+	default:
+		internalServerError(w, r, err)
+	}
 }
 
-func NewAuthenticateHandler(authService service.Authenticate) *authenticate {
-	return &authenticate{authService: authService}
+func NewAuthenticateHandler(authService service.Authenticate, verificationService service.EmailVerification) *authenticate {
+	return &authenticate{authService: authService, verificationService: verificationService}
 }
@@ -0,0 +1,421 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"github.com/julienschmidt/httprouter"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"net/http"
+	"time"
+)
+
+type company struct {
+	companyService service.Company
+	jobService     service.Job
+}
+
+// CreateCompanyHandler creates a new company.
+// @Summary Create a company
+// @Description Creates a company profile. Requires the jobs:write permission. The authenticated user becomes its owner. The slug is derived from the name when it is not given.
+// @Tags Companies
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Company body service_models.Company true "Company details"
+// @Success 201 {object} service_models.Company "Company created"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 409 {object} ErrorResponse "Slug already taken"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/companies [post]
+func (c *company) CreateCompanyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	var payload service_models.Company
+	if err := readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	if err := Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+
+	createdCompany, err := c.companyService.CreateCompany(ctx, &payload, userID)
+	if err != nil {
+		companyErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusCreated, createdCompany); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// GetAllCompaniesHandler lists companies page by page.
+// @Summary List companies
+// @Description Fetches a page of companies ordered by name, optionally filtered by name.
+// @Tags Companies
+// @Produce json
+// @Param name query string false "Filter by name"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Number of companies per page" default(20)
+// @Success 200 {array} service_models.Company "Page of companies"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/companies [get]
+func (c *company) GetAllCompaniesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	qs := r.URL.Query()
+
+	filter := &service_models.CompanyFilter{Name: readString(qs, "name", "")}
+
+	var err error
+	if filter.Page, err = readInt(qs, "page", 1); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if filter.PageSize, err = readInt(qs, "page_size", 20); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err = Validate.Struct(filter); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	companies, metadata, err := c.companyService.GetAllCompanies(ctx, filter)
+	if err != nil {
+		internalServerError(w, r, err)
+		return
+	}
+
+	if err = paginatedResponse(w, http.StatusOK, companies, metadata); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// GetCompanyHandler retrieves a company page.
+// @Summary Retrieve a company
+// @Description Fetches a company by its ID or its slug.
+// @Tags Companies
+// @Produce json
+// @Param id path string true "Company ID or slug"
+// @Success 200 {object} service_models.Company "Company details"
+// @Failure 404 {object} ErrorResponse "Company not found"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/companies/{id} [get]
+func (c *company) GetCompanyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	idOrSlug := httprouter.ParamsFromContext(r.Context()).ByName("id")
+
+	foundCompany, err := c.companyService.GetCompany(ctx, idOrSlug)
+	if err != nil {
+		companyErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, foundCompany); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// GetCompanyJobsHandler lists the open job listings of a company.
+// @Summary List a company's jobs
+// @Description Fetches a page of the published, non-expired job listings of a company. Accepts the same paging, sorting and filtering parameters as GET /v1/jobs.
+// @Tags Companies
+// @Produce json
+// @Param id path string true "Company ID or slug"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Number of jobs per page" default(20)
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page; only valid with the same sort and direction"
+// @Param sort query string false "Sort column" Enums(created_at, title, company, salary_min, salary_max) default(created_at)
+// @Param direction query string false "Sort direction" Enums(asc, desc) default(desc)
+// @Param location query string false "Filter by location"
+// @Param tags query string false "Comma-separated tag names"
+// @Param tag_mode query string false "Match jobs carrying any or all of the tags" Enums(any, all) default(any)
+// @Success 200 {object} JobPage "Page of the company's jobs"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 404 {object} ErrorResponse "Company not found"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/companies/{id}/jobs [get]
+func (c *company) GetCompanyJobsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	idOrSlug := httprouter.ParamsFromContext(r.Context()).ByName("id")
+
+	foundCompany, err := c.companyService.GetCompany(ctx, idOrSlug)
+	if err != nil {
+		companyErrorResponse(w, r, err)
+		return
+	}
+
+	filter, err := readJobFilter(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	filter.CompanyID = foundCompany.ID
+	filter.Status = ""
+	filter.PublishedOnly = true
+
+	jobs, metadata, err := c.jobService.GetAllJobs(ctx, filter)
+	if err != nil {
+		jobErrorResponse(w, r, err)
+		return
+	}
+
+	if err = paginatedResponse(w, http.StatusOK, jobs, metadata); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// UpdateCompanyHandler updates a company profile.
+// @Summary Update a company
+// @Description Updates the profile of a company. Only its owners and users with the companies:manage permission can update it. An empty slug keeps the current one.
+// @Tags Companies
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int64 true "Company ID"
+// @Param Company body service_models.Company true "Company details"
+// @Success 200 {object} service_models.Company "Updated company"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Company not found"
+// @Failure 409 {object} ErrorResponse "Slug already taken"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/companies/{id} [put]
+func (c *company) UpdateCompanyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	var payload service_models.Company
+	if err = readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	payload.ID = id
+
+	if err = Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	canManageAll := hasPermission(r, service_models.PermissionCompaniesManage)
+
+	updatedCompany, err := c.companyService.UpdateCompany(ctx, &payload, userID, canManageAll)
+	if err != nil {
+		companyErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, updatedCompany); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// DeleteCompanyHandler deletes a company.
+// @Summary Delete a company
+// @Description Deletes a company and its memberships. Its jobs are kept and stay editable by the users who posted them. Only its owners and users with the companies:manage permission can delete it.
+// @Tags Companies
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int64 true "Company ID"
+// @Success 200 {string} string "The company was successfully deleted"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Company not found"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/companies/{id} [delete]
+func (c *company) DeleteCompanyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	canManageAll := hasPermission(r, service_models.PermissionCompaniesManage)
+
+	if err = c.companyService.DeleteCompany(ctx, id, userID, canManageAll); err != nil {
+		companyErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, "the company was successfully deleted"); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// GetCompanyMembersHandler lists the members of a company.
+// @Summary List company members
+// @Description Lists the owners and recruiters of a company. Only its members and users with the companies:manage permission can see them.
+// @Tags Companies
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int64 true "Company ID"
+// @Success 200 {array} service_models.CompanyMember "Company members"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Company not found"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/companies/{id}/members [get]
+func (c *company) GetCompanyMembersHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	canManageAll := hasPermission(r, service_models.PermissionCompaniesManage)
+
+	members, err := c.companyService.GetCompanyMembers(ctx, id, userID, canManageAll)
+	if err != nil {
+		companyErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, members); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// SetCompanyMemberHandler adds a member to a company or changes their role.
+// @Summary Add or update a company member
+// @Description Makes a user an owner or recruiter of a company. Recruiters can manage the company's jobs and applications; owners can also edit the company and its members. Only owners and users with the companies:manage permission can change members, and the last owner cannot be demoted.
+// @Tags Companies
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int64 true "Company ID"
+// @Param user_id path int64 true "User ID"
+// @Param CompanyMemberPayload body service_models.CompanyMemberPayload true "Member role"
+// @Success 200 {object} service_models.CompanyMember "Company member"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Company or user not found"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/companies/{id}/members/{user_id} [put]
+func (c *company) SetCompanyMemberHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	memberID, err := readInt64Param(r, "user_id")
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	var payload service_models.CompanyMemberPayload
+	if err = readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err = Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	canManageAll := hasPermission(r, service_models.PermissionCompaniesManage)
+
+	member, err := c.companyService.SetMember(ctx, id, memberID, payload.Role, userID, canManageAll)
+	if err != nil {
+		companyErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, member); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// RemoveCompanyMemberHandler removes a member from a company.
+// @Summary Remove a company member
+// @Description Removes a user from a company. Owners and users with the companies:manage permission can remove any member and members can remove themselves. The last owner cannot be removed.
+// @Tags Companies
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int64 true "Company ID"
+// @Param user_id path int64 true "User ID"
+// @Success 200 {string} string "The member was successfully removed"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Company or member not found"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/companies/{id}/members/{user_id} [delete]
+func (c *company) RemoveCompanyMemberHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	memberID, err := readInt64Param(r, "user_id")
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	canManageAll := hasPermission(r, service_models.PermissionCompaniesManage)
+
+	if err = c.companyService.RemoveMember(ctx, id, memberID, userID, canManageAll); err != nil {
+		companyErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, "the member was successfully removed"); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+func companyErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrRecordNotFound):
+		notFoundResponse(w, r, err)
+	case errors.Is(err, repository.ErrUnAuthorized):
+		forbiddenResponse(w, r)
+	case errors.Is(err, repository.ErrDuplicateCompanySlug):
+		conflictResponse(w, r, err)
+	case errors.Is(err, repository.ErrInvalidCompanySlug),
+		errors.Is(err, repository.ErrLastCompanyOwner):
+		badRequestResponse(w, r, err)
+	default:
+		internalServerError(w, r, err)
+	}
+}
+
+func NewCompanyHandler(companyService service.Company, jobService service.Job) *company {
+	return &company{
+		companyService: companyService,
+		jobService:     jobService,
+	}
+}
@@ -48,12 +48,26 @@ func unauthorizedBasicErrorResponse(w http.ResponseWriter, r *http.Request, err
 }
 
 func forbiddenResponse(w http.ResponseWriter, r *http.Request) {
-	logger.Logger.Warn("forbidden", "method", r.Method, "path", r.URL.Path, "error")
+	logger.Logger.Warn("forbidden", "method", r.Method, "path", r.URL.Path)
 	writeJSONError(w, http.StatusForbidden, "forbidden")
 }
 
+// forbiddenErrorResponse is forbiddenResponse with a message telling the user
+// what they need to do first.
+func forbiddenErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	logger.Logger.Warn("forbidden", "method", r.Method, "path", r.URL.Path, "error", err.Error())
+	writeJSONError(w, http.StatusForbidden, err.Error())
+}
+
 func rateLimitExceededResponse(w http.ResponseWriter, r *http.Request, retryAfter string) {
 	logger.Logger.Warn("rate limit exceeded", "method", r.Method, "path", r.URL.Path)
-	w.Header().Set("Retry_After", retryAfter)
+	w.Header().Set("Retry-After", retryAfter)
 	writeJSONError(w, http.StatusTooManyRequests, "rate limit exceeded, retry after: "+retryAfter)
 }
+
+// tooManyRequestsResponse is a 429 for limits that are not a fixed rate, such
+// as lockouts, where no meaningful Retry-After can be given.
+func tooManyRequestsResponse(w http.ResponseWriter, r *http.Request, err error) {
+	logger.Logger.Warn("too many requests", "method", r.Method, "path", r.URL.Path, "error", err.Error())
+	writeJSONError(w, http.StatusTooManyRequests, err.Error())
+}
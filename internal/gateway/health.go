@@ -2,25 +2,32 @@ package gateway
 
 import (
 	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
 	"net/http"
 )
 
 // healthCheckHandler handles the health check request and returns the application's status, environment, and version.
+// When a read replica is configured it also reports the replica's health and replication lag.
 // @Summary Health check endpoint
-// @Description Returns the current status of the application, including its environment and version details.
+// @Description Returns the current status of the application, including its environment and version details. With a read replica configured, replica reports whether reads are routed to it and its replication lag in seconds; reads fall back to the primary while it is unhealthy.
 // @Tags Health
 // @Produce json
-// @Success 200 {object} map[string]string "Health check status, environment, and version"
+// @Success 200 {object} map[string]any "Health check status, environment, version and replica state"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /v1/healthcheck [get]
-func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	data := map[string]string{
-		"status":  "ok",
-		"env":     config.AppConfig.ServerConfig.Port,
-		"version": config.AppConfig.ServerConfig.Version,
-	}
-	if err := jsonResponse(w, http.StatusOK, data); err != nil {
-		internalServerError(w, r, err)
-		return
+func healthCheckHandler(replica *repository.ReplicaMonitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := map[string]any{
+			"status":  "ok",
+			"env":     config.AppConfig.ServerConfig.Port,
+			"version": config.AppConfig.ServerConfig.Version,
+		}
+		if replica != nil {
+			data["replica"] = replica.Status()
+		}
+		if err := jsonResponse(w, http.StatusOK, data); err != nil {
+			internalServerError(w, r, err)
+			return
+		}
 	}
 }
@@ -1,17 +1,73 @@
 package gateway
 
 import (
-	"errors"
+	"fmt"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
 func readIDParam(r *http.Request) (int64, error) {
+	return readInt64Param(r, "id")
+}
+
+func readInt64Param(r *http.Request, name string) (int64, error) {
 	params := httprouter.ParamsFromContext(r.Context())
-	id, err := strconv.ParseInt(params.ByName("id"), 10, 64)
+	id, err := strconv.ParseInt(params.ByName(name), 10, 64)
 	if err != nil || id < 1 {
-		return 0, errors.New("invalid id parameter")
+		return 0, fmt.Errorf("invalid %s parameter", name)
 	}
 	return id, nil
 }
+
+func readString(qs url.Values, key string, defaultValue string) string {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue
+	}
+	return s
+}
+
+func readInt(qs url.Values, key string, defaultValue int) (int, error) {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer value", key)
+	}
+	return i, nil
+}
+
+func readInt64(qs url.Values, key string, defaultValue int64) (int64, error) {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue, nil
+	}
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer value", key)
+	}
+	return i, nil
+}
+
+// readCSV reads a comma-separated list; repeated keys are joined as well, so
+// both ?tags=go,sql and ?tags=go&tags=sql work.
+func readCSV(qs url.Values, key string, defaultValue []string) []string {
+	values := qs[key]
+	if len(values) == 0 {
+		return defaultValue
+	}
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
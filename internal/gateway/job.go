@@ -2,6 +2,8 @@ package gateway
 
 import (
 	"context"
+	"errors"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
 	"net/http"
@@ -12,9 +14,16 @@ type job struct {
 	jobService service.Job
 }
 
+// JobPage is the body of the job list endpoints. Metadata.NextCursor pages on
+// with the same sort and direction.
+type JobPage struct {
+	Data     []*service_models.Job   `json:"data"`
+	Metadata service_models.Metadata `json:"metadata"`
+}
+
 // CreateJobHandler creates a new job listing.
 // @Summary Create a new job listing
-// @Description Creates a new job listing with the provided job details. The job is associated with the authenticated user.
+// @Description Creates a new job listing with the provided job details. The job is associated with the authenticated user and starts as a draft until it is published. When company_id is set the user must be a member of that company, and the company name is taken from it. Tags must already exist; see GET /v1/tags.
 // @Tags Jobs
 // @Accept json
 // @Produce json
@@ -26,7 +35,7 @@ type job struct {
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /v1/jobs [post]
 func (j *job) CreateJobHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 	var jobs service_models.Job
 	if err := readJSON(w, r, &jobs); err != nil {
@@ -40,11 +49,12 @@ func (j *job) CreateJobHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userID := r.Context().Value("userID").(int64)
+	canManageAll := hasPermission(r, service_models.PermissionJobsManage)
 	jobs.UserID = userID
 
-	createdJob, err := j.jobService.CreateJob(ctx, &jobs)
+	createdJob, err := j.jobService.CreateJob(ctx, &jobs, canManageAll)
 	if err != nil {
-		internalServerError(w, r, err)
+		jobErrorResponse(w, r, err)
 		return
 	}
 
@@ -54,52 +64,181 @@ func (j *job) CreateJobHandler(w http.ResponseWriter, r *http.Request) {
 
 }
 
-// GetAllJobsHandler retrieves all job listings.
-// @Summary Retrieve all job listings
-// @Description Fetches a list of all job listings available in the system.
+// GetAllJobsHandler retrieves job listings page by page.
+// @Summary Retrieve job listings
+// @Description Fetches a page of published, non-expired job listings. Results can be filtered by company, location and user, sorted by created_at, title or company, and paginated either by page number or by the cursor returned in the metadata.
 // @Tags Jobs
 // @Produce json
-// @Security ApiKeyAuth
-// @Success 200 {array} service_models.Job "List of all jobs"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Number of jobs per page" default(20)
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page; only valid with the same sort and direction"
+// @Param sort query string false "Sort column" Enums(created_at, title, company, salary_min, salary_max) default(created_at)
+// @Param direction query string false "Sort direction" Enums(asc, desc) default(desc)
+// @Param company query string false "Filter by company"
+// @Param company_id query int false "Filter by company ID"
+// @Param tags query string false "Comma-separated tag names"
+// @Param tag_mode query string false "Match jobs carrying any or all of the tags" Enums(any, all) default(any)
+// @Param location query string false "Filter by location"
+// @Param user_id query int false "Filter by the user who posted the job"
+// @Param salary_min query int false "Only jobs paying at least this amount; requires currency"
+// @Param salary_max query int false "Only jobs starting at or below this amount; requires currency"
+// @Param currency query string false "ISO 4217 salary currency, e.g. EUR"
+// @Param period query string false "Salary pay period" Enums(hourly, monthly, yearly)
+// @Success 200 {object} JobPage "Page of jobs"
+// @Failure 400 {object} ErrorResponse "Bad Request"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /v1/jobs [get]
 func (j *job) GetAllJobsHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	jobs, err := j.jobService.GetAllJobs(ctx)
+	filter, err := readJobFilter(r)
 	if err != nil {
-		internalServerError(w, r, err)
+		badRequestResponse(w, r, err)
 		return
 	}
+	filter.Status = ""
+	filter.PublishedOnly = true
 
-	if err = jsonResponse(w, http.StatusOK, jobs); err != nil {
-		internalServerError(w, r, err)
-		return
-	}
+	j.listJobs(ctx, w, r, filter)
 }
 
-// GetAllJobsByUserHandler retrieves all job listings by a specific user.
-// @Summary Retrieve all job listings by user ID
-// @Description Fetches a list of all job listings associated with a specific user based on the user ID.
+// GetAllJobsByUserHandler retrieves the job listings of the authenticated user.
+// @Summary Retrieve the authenticated user's job listings
+// @Description Fetches a page of the job listings posted by the authenticated user, including drafts, closed, expired and archived jobs. Accepts the same paging, sorting and filtering parameters as GET /v1/jobs, plus a status filter.
 // @Tags Jobs
 // @Produce json
 // @Security ApiKeyAuth
-// @Param userID path int64 true "User ID"
-// @Success 200 {array} service_models.Job "List of jobs for the specified user"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Number of jobs per page" default(20)
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page; only valid with the same sort and direction"
+// @Param sort query string false "Sort column" Enums(created_at, title, company, salary_min, salary_max) default(created_at)
+// @Param direction query string false "Sort direction" Enums(asc, desc) default(desc)
+// @Param company query string false "Filter by company"
+// @Param company_id query int false "Filter by company ID"
+// @Param tags query string false "Comma-separated tag names"
+// @Param tag_mode query string false "Match jobs carrying any or all of the tags" Enums(any, all) default(any)
+// @Param location query string false "Filter by location"
+// @Param status query string false "Filter by status" Enums(draft, published, closed, expired, archived)
+// @Param salary_min query int false "Only jobs paying at least this amount; requires currency"
+// @Param salary_max query int false "Only jobs starting at or below this amount; requires currency"
+// @Param currency query string false "ISO 4217 salary currency, e.g. EUR"
+// @Param period query string false "Salary pay period" Enums(hourly, monthly, yearly)
+// @Success 200 {object} JobPage "Page of jobs for the authenticated user"
+// @Failure 400 {object} ErrorResponse "Bad Request"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /v1/jobsByUser [get]
 func (j *job) GetAllJobsByUserHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
-	userID := r.Context().Value("userID").(int64)
-	jobs, err := j.jobService.GetAllJobsByUserID(ctx, userID)
+
+	filter, err := readJobFilter(r)
 	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	filter.UserID = r.Context().Value("userID").(int64)
+
+	j.listJobs(ctx, w, r, filter)
+}
+
+func (j *job) listJobs(ctx context.Context, w http.ResponseWriter, r *http.Request, filter *service_models.JobFilter) {
+	jobs, metadata, err := j.jobService.GetAllJobs(ctx, filter)
+	if err != nil {
+		jobErrorResponse(w, r, err)
+		return
+	}
+
+	if err = paginatedResponse(w, http.StatusOK, jobs, metadata); err != nil {
 		internalServerError(w, r, err)
 		return
 	}
+}
 
-	if err = jsonResponse(w, http.StatusOK, jobs); err != nil {
+func readJobFilter(r *http.Request) (*service_models.JobFilter, error) {
+	qs := r.URL.Query()
+
+	filter := &service_models.JobFilter{
+		Cursor:    readString(qs, "cursor", ""),
+		Sort:      readString(qs, "sort", "created_at"),
+		Direction: readString(qs, "direction", "desc"),
+		Company:   readString(qs, "company", ""),
+		Location:  readString(qs, "location", ""),
+		Status:    readString(qs, "status", ""),
+		Currency:  readString(qs, "currency", ""),
+		Period:    readString(qs, "period", ""),
+		Tags:      service_models.NormalizeTags(readCSV(qs, "tags", nil)),
+		TagMode:   readString(qs, "tag_mode", "any"),
+	}
+
+	var err error
+	if filter.Page, err = readInt(qs, "page", 1); err != nil {
+		return nil, err
+	}
+	if filter.PageSize, err = readInt(qs, "page_size", 20); err != nil {
+		return nil, err
+	}
+	if filter.UserID, err = readInt64(qs, "user_id", 0); err != nil {
+		return nil, err
+	}
+	if filter.CompanyID, err = readInt64(qs, "company_id", 0); err != nil {
+		return nil, err
+	}
+	if filter.SalaryMin, err = readInt64(qs, "salary_min", 0); err != nil {
+		return nil, err
+	}
+	if filter.SalaryMax, err = readInt64(qs, "salary_max", 0); err != nil {
+		return nil, err
+	}
+
+	if err = Validate.Struct(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// SearchJobsHandler runs a full-text search over job listings.
+// @Summary Search job listings
+// @Description Searches the title, company and description of job listings. Results are ranked by relevance and include highlighted snippets.
+// @Tags Jobs
+// @Produce json
+// @Param q query string true "Search terms; quoted phrases, OR and -exclusions are supported"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Number of results per page" default(20)
+// @Success 200 {array} service_models.JobSearchResult "Ranked search results"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/search/jobs [get]
+func (j *job) SearchJobsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	qs := r.URL.Query()
+	filter := &service_models.JobSearchFilter{
+		Query: readString(qs, "q", ""),
+	}
+
+	var err error
+	if filter.Page, err = readInt(qs, "page", 1); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if filter.PageSize, err = readInt(qs, "page_size", 20); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err = Validate.Struct(filter); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	results, metadata, err := j.jobService.SearchJobs(ctx, filter)
+	if err != nil {
+		internalServerError(w, r, err)
+		return
+	}
+
+	if err = paginatedResponse(w, http.StatusOK, results, metadata); err != nil {
 		internalServerError(w, r, err)
 		return
 	}
@@ -107,26 +246,30 @@ func (j *job) GetAllJobsByUserHandler(w http.ResponseWriter, r *http.Request) {
 
 // GetJobByIdHandler retrieves a specific job listing by its ID.
 // @Summary Retrieve a job listing by ID
-// @Description Fetches a job listing based on the provided job ID.
+// @Description Fetches a job listing based on the provided job ID. Drafts, closed and expired jobs are only visible to their owner, the members of its company and users with the jobs:manage permission.
 // @Tags Jobs
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path int64 true "Job ID"
 // @Success 200 {object} service_models.Job "Job listing details"
 // @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 404 {object} ErrorResponse "Job not found"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /v1/jobs/{id} [get]
 func (j *job) GetJobByIdHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 	id, err := readIDParam(r)
 	if err != nil {
 		badRequestResponse(w, r, err)
 		return
 	}
-	jobs, err := j.jobService.GetJobById(ctx, id)
+	userID := r.Context().Value("userID").(int64)
+	canManageAll := hasPermission(r, service_models.PermissionJobsManage)
+
+	jobs, err := j.jobService.GetJobById(ctx, id, userID, canManageAll)
 	if err != nil {
-		internalServerError(w, r, err)
+		jobErrorResponse(w, r, err)
 		return
 	}
 
@@ -138,7 +281,7 @@ func (j *job) GetJobByIdHandler(w http.ResponseWriter, r *http.Request) {
 
 // UpdateJobHandler updates an existing job listing.
 // @Summary Update an existing job listing
-// @Description Updates the details of a job listing, based on the provided job ID and job data.
+// @Description Updates the details of a job listing, based on the provided job ID and job data. The status cannot be changed here; use the publish and close endpoints. Members of the job's company can edit it as well as its owner; an omitted company_id, tags list or expires_at keeps the current value. Set clear_expires_at to remove the expiry.
 // @Tags Jobs
 // @Produce json
 // @Security ApiKeyAuth
@@ -146,11 +289,12 @@ func (j *job) GetJobByIdHandler(w http.ResponseWriter, r *http.Request) {
 // @Param job body service_models.Job true "Job data to update"
 // @Success 200 {object} service_models.Job "Updated job details"
 // @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Job not found"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /v1/jobs/{id} [put]
 func (j *job) UpdateJobHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO:Fix the issue of update in the update Job handler
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 	id, err := readIDParam(r)
 	if err != nil {
@@ -158,12 +302,11 @@ func (j *job) UpdateJobHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var jobs service_models.Job
-	jobs.ID = id
-
 	if err = readJSON(w, r, &jobs); err != nil {
 		badRequestResponse(w, r, err)
 		return
 	}
+	jobs.ID = id
 
 	if err = Validate.Struct(jobs); err != nil {
 		badRequestResponse(w, r, err)
@@ -171,11 +314,11 @@ func (j *job) UpdateJobHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userID := r.Context().Value("userID").(int64)
-	isAdmin := r.Context().Value("isAdmin").(bool)
+	canManageAll := hasPermission(r, service_models.PermissionJobsManage)
 
-	updateJob, err := j.jobService.UpdateJob(ctx, &jobs, userID, isAdmin)
+	updateJob, err := j.jobService.UpdateJob(ctx, &jobs, userID, canManageAll)
 	if err != nil {
-		internalServerError(w, r, err)
+		jobErrorResponse(w, r, err)
 		return
 	}
 
@@ -188,7 +331,7 @@ func (j *job) UpdateJobHandler(w http.ResponseWriter, r *http.Request) {
 
 // DeleteJobHandler deletes an existing job listing.
 // @Summary Delete a job listing
-// @Description Deletes a job listing by its ID. Only the user who created the job or an admin can delete it.
+// @Description Deletes a job listing by its ID. Only the user who created the job, the members of its company or a user with the jobs:manage permission can delete it.
 // @Tags Jobs
 // @Produce json
 // @Security ApiKeyAuth
@@ -198,7 +341,7 @@ func (j *job) UpdateJobHandler(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /v1/jobs/{id} [delete]
 func (j *job) DeleteJobHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 	id, err := readIDParam(r)
 	if err != nil {
@@ -207,10 +350,10 @@ func (j *job) DeleteJobHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userID := r.Context().Value("userID").(int64)
-	isAdmin := r.Context().Value("isAdmin").(bool)
+	canManageAll := hasPermission(r, service_models.PermissionJobsManage)
 
-	if err = j.jobService.DeleteJob(ctx, id, userID, isAdmin); err != nil {
-		internalServerError(w, r, err)
+	if err = j.jobService.DeleteJob(ctx, id, userID, canManageAll); err != nil {
+		jobErrorResponse(w, r, err)
 		return
 	}
 
@@ -220,6 +363,99 @@ func (j *job) DeleteJobHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// PublishJobHandler publishes a job listing.
+// @Summary Publish a job listing
+// @Description Makes a draft, closed or expired job listing publicly visible. Archived jobs cannot be published again. A job whose expires_at has passed must be given a new expiry first. Only the owner of the job, the members of its company or a user with the jobs:manage permission can publish it.
+// @Tags Jobs
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int64 true "Job ID"
+// @Success 200 {object} service_models.Job "Published job"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Job not found"
+// @Failure 409 {object} ErrorResponse "Edit conflict"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/jobs/{id}/publish [post]
+func (j *job) PublishJobHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	canManageAll := hasPermission(r, service_models.PermissionJobsManage)
+
+	publishedJob, err := j.jobService.PublishJob(ctx, id, userID, canManageAll)
+	if err != nil {
+		jobErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, publishedJob); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// CloseJobHandler closes a published job listing.
+// @Summary Close a job listing
+// @Description Takes a published job listing down without deleting it. Closed jobs stop accepting applications and can be published again later. Only the owner of the job, the members of its company or a user with the jobs:manage permission can close it.
+// @Tags Jobs
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int64 true "Job ID"
+// @Success 200 {object} service_models.Job "Closed job"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Job not found"
+// @Failure 409 {object} ErrorResponse "Edit conflict"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/jobs/{id}/close [post]
+func (j *job) CloseJobHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	canManageAll := hasPermission(r, service_models.PermissionJobsManage)
+
+	closedJob, err := j.jobService.CloseJob(ctx, id, userID, canManageAll)
+	if err != nil {
+		jobErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, closedJob); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+func jobErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrRecordNotFound):
+		notFoundResponse(w, r, err)
+	case errors.Is(err, repository.ErrUnAuthorized):
+		forbiddenResponse(w, r)
+	case errors.Is(err, repository.ErrEditConflict):
+		conflictResponse(w, r, err)
+	case errors.Is(err, repository.ErrInvalidCursor),
+		errors.Is(err, repository.ErrInvalidExpiry),
+		errors.Is(err, repository.ErrInvalidJobStatus),
+		errors.Is(err, repository.ErrInvalidSalary),
+		errors.Is(err, repository.ErrUnknownTag):
+		badRequestResponse(w, r, err)
+	default:
+		internalServerError(w, r, err)
+	}
+}
+
 func NewJob(jobService service.Job) *job {
 	return &job{
 		jobService: jobService,
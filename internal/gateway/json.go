@@ -36,3 +36,11 @@ func jsonResponse(w http.ResponseWriter, status int, data any) error {
 	}
 	return writeJSON(w, status, envelope{Data: data})
 }
+
+func paginatedResponse(w http.ResponseWriter, status int, data any, metadata any) error {
+	type envelope struct {
+		Data     any `json:"data"`
+		Metadata any `json:"metadata"`
+	}
+	return writeJSON(w, status, envelope{Data: data, Metadata: metadata})
+}
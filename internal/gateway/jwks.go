@@ -0,0 +1,23 @@
+package gateway
+
+import (
+	"github.com/saleh-ghazimoradi/GoJobs/utils"
+	"net/http"
+)
+
+// jwksHandler publishes the public keys that access tokens are signed with, so
+// other services can verify them without sharing a secret. The body is a bare
+// RFC 7517 key set rather than the usual data envelope, as JWKS clients expect.
+// @Summary JSON Web Key Set
+// @Description Returns the public keys used to sign access tokens. Tokens carry the key ID in their kid header, are issued by JWT_ISSUER for the JWT_AUDIENCE audience and use EdDSA or RS256. New keys are published here before they start signing, and old keys stay until tokens signed with them have expired.
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} utils.JSONWebKeySet "Public signing keys"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /.well-known/jwks.json [get]
+func jwksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := writeJSON(w, http.StatusOK, utils.JWKS()); err != nil {
+		internalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,286 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type mfa struct {
+	mfaService service.MFA
+}
+
+// GetMFAStatusHandler shows the two-factor state of the current user.
+// @Summary Two-factor status
+// @Description Shows whether TOTP is enabled for the authenticated user, how many recovery codes are left and whether admins must use two-factor authentication.
+// @Tags MFA
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} service_models.MFAStatus "Two-factor status"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/mfa [get]
+func (m *mfa) GetMFAStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userID := r.Context().Value("userID").(int64)
+	status, err := m.mfaService.GetMFAStatus(ctx, userID)
+	if err != nil {
+		mfaErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, status); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// BeginTOTPEnrollmentHandler starts TOTP enrollment.
+// @Summary Start TOTP enrollment
+// @Description Generates a TOTP secret for the authenticated user and returns it with an otpauth:// URI for authenticator apps. Two-factor authentication is only enabled once a code is confirmed with POST /v1/mfa/totp/confirm.
+// @Tags MFA
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 201 {object} service_models.TOTPEnrollment "Secret and otpauth URI"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 409 {object} ErrorResponse "Already enabled"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/mfa/totp [post]
+func (m *mfa) BeginTOTPEnrollmentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	userID := r.Context().Value("userID").(int64)
+	enrollment, err := m.mfaService.BeginTOTPEnrollment(ctx, userID)
+	if err != nil {
+		mfaErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusCreated, enrollment); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// ConfirmTOTPEnrollmentHandler enables TOTP.
+// @Summary Confirm TOTP enrollment
+// @Description Enables two-factor authentication once the first code from the authenticator app checks out, and returns one-time recovery codes. The codes are shown only once.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param MFACodePayload body service_models.MFACodePayload true "Code from the authenticator app"
+// @Success 200 {object} service_models.RecoveryCodes "Recovery codes"
+// @Failure 400 {object} ErrorResponse "Bad Request or invalid code"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 409 {object} ErrorResponse "Already enabled or enrollment not started"
+// @Failure 429 {object} ErrorResponse "Too many failed codes"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/mfa/totp/confirm [post]
+func (m *mfa) ConfirmTOTPEnrollmentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var payload service_models.MFACodePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err := Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	codes, err := m.mfaService.ConfirmTOTPEnrollment(ctx, userID, payload.Code)
+	if err != nil {
+		mfaErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, codes); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// DisableTOTPHandler disables TOTP.
+// @Summary Disable TOTP
+// @Description Turns two-factor authentication off after checking a current TOTP or recovery code. Admins cannot turn it off while it is mandatory for admin accounts.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param MFACodePayload body service_models.MFACodePayload true "TOTP or recovery code"
+// @Success 200 {string} string "Two-factor authentication disabled"
+// @Failure 400 {object} ErrorResponse "Bad Request or invalid code"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Mandatory for admins"
+// @Failure 409 {object} ErrorResponse "Not enabled"
+// @Failure 429 {object} ErrorResponse "Too many failed codes"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/mfa/totp [delete]
+func (m *mfa) DisableTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var payload service_models.MFACodePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err := Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	if err := m.mfaService.DisableTOTP(ctx, userID, payload.Code); err != nil {
+		mfaErrorResponse(w, r, err)
+		return
+	}
+
+	if err := jsonResponse(w, http.StatusOK, "two-factor authentication was successfully disabled"); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// RegenerateRecoveryCodesHandler replaces the recovery codes.
+// @Summary Regenerate recovery codes
+// @Description Replaces every recovery code of the authenticated user after checking a current TOTP or recovery code.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param MFACodePayload body service_models.MFACodePayload true "TOTP or recovery code"
+// @Success 200 {object} service_models.RecoveryCodes "New recovery codes"
+// @Failure 400 {object} ErrorResponse "Bad Request or invalid code"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 409 {object} ErrorResponse "Not enabled"
+// @Failure 429 {object} ErrorResponse "Too many failed codes"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/mfa/recovery-codes [post]
+func (m *mfa) RegenerateRecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var payload service_models.MFACodePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err := Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	userID := r.Context().Value("userID").(int64)
+	codes, err := m.mfaService.RegenerateRecoveryCodes(ctx, userID, payload.Code)
+	if err != nil {
+		mfaErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, codes); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// GetMFAPolicyHandler shows the two-factor policy.
+// @Summary Get two-factor policy
+// @Description Shows whether two-factor authentication is mandatory for admin accounts. Requires the security:manage permission.
+// @Tags MFA
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} service_models.MFAPolicy "Two-factor policy"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/admin/mfa-policy [get]
+func (m *mfa) GetMFAPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	policy, err := m.mfaService.GetMFAPolicy(ctx)
+	if err != nil {
+		internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, policy); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// UpdateMFAPolicyHandler changes the two-factor policy.
+// @Summary Update two-factor policy
+// @Description Makes two-factor authentication mandatory for admin accounts, or optional again. Requires the security:manage permission. While it is mandatory, the admin role grants nothing to sessions started without a second factor, so it can only be turned on from a session started with two-factor authentication.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param MFAPolicyPayload body service_models.MFAPolicyPayload true "Two-factor policy"
+// @Success 200 {object} service_models.MFAPolicy "Updated policy"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/admin/mfa-policy [put]
+func (m *mfa) UpdateMFAPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var payload service_models.MFAPolicyPayload
+	if err := readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err := Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	if *payload.RequireAdminMFA && !r.Context().Value("mfa").(bool) {
+		forbiddenErrorResponse(w, r, errors.New("log in with two-factor authentication before making it mandatory for admins"))
+		return
+	}
+
+	policy, err := m.mfaService.UpdateMFAPolicy(ctx, *payload.RequireAdminMFA)
+	if err != nil {
+		internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, policy); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+func mfaErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrInvalidMFACode):
+		badRequestResponse(w, r, err)
+	case errors.Is(err, repository.ErrInvalidMFAToken):
+		unauthorizedErrorResponse(w, r, err)
+	case errors.Is(err, repository.ErrMFALocked):
+		rateLimitExceededResponse(w, r, strconv.Itoa(int(config.AppConfig.MFA.LockoutPeriod.Seconds())))
+	case errors.Is(err, repository.ErrMFARequired):
+		forbiddenErrorResponse(w, r, err)
+	case errors.Is(err, repository.ErrMFAAlreadyEnabled), errors.Is(err, repository.ErrMFANotEnabled), errors.Is(err, repository.ErrMFANotStarted):
+		conflictResponse(w, r, err)
+	case errors.Is(err, repository.ErrRecordNotFound):
+		notFoundResponse(w, r, err)
+	default:
+		internalServerError(w, r, err)
+	}
+}
+
+func NewMFAHandler(mfaService service.MFA) *mfa {
+	return &mfa{
+		mfaService: mfaService,
+	}
+}
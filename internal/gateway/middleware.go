@@ -2,32 +2,143 @@ package gateway
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/saleh-ghazimoradi/GoJobs/utils"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
 	"net/http"
+	"slices"
+	"strings"
 )
 
-func AuthMiddleware(next http.Handler) http.Handler {
+// AuthMiddleware returns a middleware that authenticates requests with an
+// access token, sent as "Authorization: Bearer <token>" or, for older clients,
+// as the bare token, or with an API key, sent in the X-API-Key header or as the
+// bearer token. Routes that must not be reachable with API keys wrap their
+// handler in sessionOnly.
+func AuthMiddleware(authService service.Authenticate, apiKeyService service.APIKey) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, apiKey := readCredentials(r)
+			if token == "" && apiKey == "" {
+				unauthorizedErrorResponse(w, r, fmt.Errorf("authorization token missing"))
+				return
+			}
+
+			var principal *service_models.Principal
+			var err error
+			if apiKey != "" {
+				principal, err = apiKeyService.Authenticate(r.Context(), apiKey)
+			} else {
+				principal, err = authService.ValidateAccessToken(r.Context(), token)
+			}
+			if err != nil {
+				switch {
+				case errors.Is(err, repository.ErrTokenRevoked), errors.Is(err, repository.ErrInvalidAPIKey):
+					unauthorizedErrorResponse(w, r, err)
+				default:
+					unauthorizedErrorResponse(w, r, fmt.Errorf("invalid authorization token"))
+				}
+				return
+			}
+
+			ctx := r.Context()
+			ctx = context.WithValue(ctx, "userID", principal.UserID)
+			ctx = context.WithValue(ctx, "mfa", principal.MFA)
+			ctx = context.WithValue(ctx, "permissions", principal.Permissions)
+			ctx = context.WithValue(ctx, "apiKey", principal.APIKeyID != nil)
+			ctx = context.WithValue(ctx, "scopes", principal.Scopes)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// readCredentials returns either the access token or the API key of r.
+func readCredentials(r *http.Request) (token, apiKey string) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return "", key
+	}
+
+	header := r.Header.Get("Authorization")
+	if scheme, credentials, found := strings.Cut(header, " "); found && strings.EqualFold(scheme, "Bearer") {
+		header = strings.TrimSpace(credentials)
+	}
+	if strings.HasPrefix(header, service_models.APIKeyPrefix) {
+		return "", header
+	}
+	return header, ""
+}
+
+// sessionOnly rejects requests authenticated with an API key. Account,
+// security and administration routes use it so that a leaked integration key
+// cannot take over the account that created it.
+func sessionOnly(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get("Authorization")
-		if token == "" {
-			unauthorizedErrorResponse(w, r, fmt.Errorf("authorization token missing"))
+		if isAPIKey, _ := r.Context().Value("apiKey").(bool); isAPIKey {
+			forbiddenErrorResponse(w, r, errors.New("this endpoint cannot be used with an API key"))
 			return
 		}
+		next.ServeHTTP(w, r)
+	})
+}
 
-		claims, err := utils.ValidateToken(token)
+// requireScope rejects requests authenticated with an API key that has none
+// of scopes. It guards routes that check ownership rather than a permission;
+// requests with an access token always pass.
+func requireScope(scopes []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isAPIKey, _ := r.Context().Value("apiKey").(bool); isAPIKey {
+			granted, _ := r.Context().Value("scopes").([]string)
+			if !slices.ContainsFunc(scopes, func(scope string) bool { return slices.Contains(granted, scope) }) {
+				forbiddenResponse(w, r)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
 
-		if err != nil {
-			unauthorizedErrorResponse(w, r, fmt.Errorf("authorization token missing"))
+// requirePermission rejects requests whose user lacks permission. It must run
+// after the auth middleware.
+func requirePermission(permission string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasPermission(r, permission) {
+			forbiddenResponse(w, r)
 			return
 		}
+		next.ServeHTTP(w, r)
+	})
+}
 
-		ctx := r.Context()
-		ctx = context.WithValue(ctx, "userID", claims.UserID)
-		ctx = context.WithValue(ctx, "isAdmin", claims.IsAdmin)
-		next.ServeHTTP(w, r.WithContext(ctx))
+// hasPermission reports whether the authenticated user of r has permission.
+func hasPermission(r *http.Request, permission string) bool {
+	permissions, _ := r.Context().Value("permissions").([]string)
+	return slices.Contains(permissions, permission)
+}
 
-	})
+// VerifiedEmailMiddleware returns a middleware that rejects users whose email
+// address is not verified. It lets every request through when required is
+// false, so the policy can be switched per route from the config.
+func VerifiedEmailMiddleware(verificationService service.EmailVerification, required bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !required {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := r.Context().Value("userID").(int64)
+			if err := verificationService.RequireVerified(r.Context(), userID); err != nil {
+				switch {
+				case errors.Is(err, repository.ErrEmailNotVerified):
+					forbiddenErrorResponse(w, r, err)
+				default:
+					internalServerError(w, r, err)
+				}
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
 func recoverPanic(next http.Handler) http.Handler {
@@ -41,3 +152,11 @@ func recoverPanic(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// pinPrimaryAfterWrite routes a request's reads to the primary database once
+// it has written, so it never reads a replica that has not caught up yet.
+func pinPrimaryAfterWrite(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(repository.WithPrimaryPin(r.Context())))
+	})
+}
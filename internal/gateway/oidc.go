@@ -0,0 +1,131 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
+	"net/http"
+	"time"
+)
+
+// oidcFlowCookie carries the sealed state of a sign-in between the login
+// redirect and the callback.
+const oidcFlowCookie = "gojobs_oidc_flow"
+
+type openIDConnect struct {
+	oidcService service.OIDC
+}
+
+// LoginHandler starts a sign-in with the identity provider.
+// @Summary Start single sign-on
+// @Description Redirects the browser to the OpenID Connect provider to sign in with the authorization code flow and PKCE. The flow state is kept in a short-lived cookie, so the callback must happen in the same browser.
+// @Tags Authentication
+// @Success 302 {string} string "Redirect to the identity provider"
+// @Failure 404 {object} ErrorResponse "Single sign-on is not configured"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/oidc/login [get]
+func (o *openIDConnect) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	authURL, flow, err := o.oidcService.BeginLogin(ctx)
+	if err != nil {
+		oidcErrorResponse(w, r, err)
+		return
+	}
+
+	setOIDCFlowCookie(w, flow, config.AppConfig.OIDC.StateTTL)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// CallbackHandler completes a sign-in with the identity provider.
+// @Summary Complete single sign-on
+// @Description Receives the authorization code from the OpenID Connect provider, verifies the ID token against the provider's published keys and logs in the linked user. On the first sign-in the identity is linked to the user with the same verified email address or a new user without a password is created, depending on the configuration. Accounts with two-factor authentication get mfa_required and an mfa_token, to be completed with POST /v1/login/mfa.
+// @Tags Authentication
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State from the login redirect"
+// @Success 200 {object} service_models.LoginResult "Access and refresh tokens, or a two-factor challenge"
+// @Failure 400 {object} ErrorResponse "Invalid or expired sign-in session"
+// @Failure 401 {object} ErrorResponse "Sign-in failed"
+// @Failure 403 {object} ErrorResponse "No verified email or no linked account"
+// @Failure 404 {object} ErrorResponse "Single sign-on is not configured"
+// @Failure 409 {object} ErrorResponse "Email already used by another account"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/oidc/callback [get]
+func (o *openIDConnect) CallbackHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	// The flow works once, whatever the outcome.
+	setOIDCFlowCookie(w, "", -1)
+
+	qs := r.URL.Query()
+	if providerErr := qs.Get("error"); providerErr != "" {
+		oidcErrorResponse(w, r, fmt.Errorf("%w: %s: %s", repository.ErrOIDCLoginFailed, providerErr, qs.Get("error_description")))
+		return
+	}
+
+	cookie, err := r.Cookie(oidcFlowCookie)
+	if err != nil {
+		oidcErrorResponse(w, r, repository.ErrInvalidOIDCState)
+		return
+	}
+
+	result, err := o.oidcService.CompleteLogin(ctx, cookie.Value, qs.Get("state"), qs.Get("code"))
+	if err != nil {
+		oidcErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, result); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// setOIDCFlowCookie stores the sealed flow, or deletes the cookie when ttl is
+// negative. It is Lax rather than Strict because the callback is a top-level
+// navigation from the provider's site.
+func setOIDCFlowCookie(w http.ResponseWriter, value string, ttl time.Duration) {
+	maxAge := int(ttl.Seconds())
+	if ttl < 0 {
+		maxAge = -1
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcFlowCookie,
+		Value:    value,
+		Path:     "/v1/oidc",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   config.AppConfig.OIDC.CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func oidcErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrOIDCDisabled):
+		notFoundResponse(w, r, err)
+	case errors.Is(err, repository.ErrInvalidOIDCState):
+		badRequestResponse(w, r, err)
+	case errors.Is(err, repository.ErrOIDCLoginFailed):
+		unauthorizedErrorResponse(w, r, err)
+	case errors.Is(err, repository.ErrOIDCEmailRequired), errors.Is(err, repository.ErrOIDCAccountNotLinked):
+		forbiddenErrorResponse(w, r, err)
+	case errors.Is(err, repository.ErrDuplicateEmails), errors.Is(err, repository.ErrDuplicateUsernames), errors.Is(err, repository.ErrDuplicateIdentity):
+		conflictResponse(w, r, err)
+	default:
+		internalServerError(w, r, err)
+	}
+}
+
+func NewOIDCHandler(oidcService service.OIDC) *openIDConnect {
+	return &openIDConnect{
+		oidcService: oidcService,
+	}
+}
@@ -0,0 +1,81 @@
+package gateway
+
+import (
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"github.com/saleh-ghazimoradi/GoJobs/logger"
+	"github.com/saleh-ghazimoradi/GoJobs/ratelimit"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitMiddleware returns a middleware that takes a token from the bucket
+// of the client in group for every request. Clients are identified by user ID
+// when the auth middleware ran first and by IP otherwise. The RateLimit-*
+// headers describe the innermost group that applies to a route. A failing
+// store lets requests through rather than taking the API down with it.
+func RateLimitMiddleware(store ratelimit.Store, group string, limit ratelimit.Limit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if store == nil || !config.AppConfig.RateLimit.Enabled || !limit.Enabled() {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := store.Take(r.Context(), rateLimitKey(r, group), limit)
+			if err != nil {
+				logger.Logger.Error("rate limit store failed", "group", group, "error", err.Error())
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", ceilSeconds(result.Reset))
+
+			if !result.Allowed {
+				rateLimitExceededResponse(w, r, ceilSeconds(result.RetryAfter))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func rateLimitKey(r *http.Request, group string) string {
+	if userID, ok := r.Context().Value("userID").(int64); ok {
+		return fmt.Sprintf("%s:user:%d", group, userID)
+	}
+	return fmt.Sprintf("%s:ip:%s", group, clientIP(r))
+}
+
+// clientIP returns the IP address of the client. Behind trusted proxies it is
+// the X-Forwarded-For entry appended by the outermost one, TrustedProxyHops
+// entries from the right. Entries left of it come from the client, which can
+// put anything there.
+func clientIP(r *http.Request) string {
+	if hops := config.AppConfig.RateLimit.TrustedProxyHops; hops > 0 {
+		var entries []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, entry := range strings.Split(header, ",") {
+				entries = append(entries, strings.TrimSpace(entry))
+			}
+		}
+		if len(entries) > 0 {
+			return entries[max(len(entries)-hops, 0)]
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
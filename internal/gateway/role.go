@@ -0,0 +1,338 @@
+package gateway
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"net/http"
+	"time"
+)
+
+type role struct {
+	roleService service.Role
+	transactor  service.Transactor
+}
+
+// GetAllPermissionsHandler lists the permissions roles can grant.
+// @Summary List permissions
+// @Description Lists every permission a role can grant. Requires the roles:manage permission.
+// @Tags Roles
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} service_models.Permission "Permissions"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/permissions [get]
+func (ro *role) GetAllPermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	permissions, err := ro.roleService.GetAllPermissions(ctx)
+	if err != nil {
+		internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, permissions); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// GetAllRolesHandler lists roles.
+// @Summary List roles
+// @Description Lists every role with its permissions. Requires the roles:manage permission.
+// @Tags Roles
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} service_models.Role "Roles"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/roles [get]
+func (ro *role) GetAllRolesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	roles, err := ro.roleService.GetAllRoles(ctx)
+	if err != nil {
+		internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, roles); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// GetRoleHandler shows a role.
+// @Summary Get a role
+// @Description Shows a role with its permissions. Requires the roles:manage permission.
+// @Tags Roles
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int64 true "Role ID"
+// @Success 200 {object} service_models.Role "Role"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Role not found"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/roles/{id} [get]
+func (ro *role) GetRoleHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	foundRole, err := ro.roleService.GetRoleById(ctx, id)
+	if err != nil {
+		roleErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, foundRole); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// CreateRoleHandler creates a role.
+// @Summary Create a role
+// @Description Creates a role granting the listed permissions. Names are stored in lowercase. Requires the roles:manage permission.
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param RolePayload body service_models.RolePayload true "Role"
+// @Success 201 {object} service_models.Role "Role created"
+// @Failure 400 {object} ErrorResponse "Bad Request or unknown permission"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 409 {object} ErrorResponse "Role already exists"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/roles [post]
+func (ro *role) CreateRoleHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var payload service_models.RolePayload
+	if err := readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err := Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	createdRole, err := ro.roleService.CreateRole(ctx, &service_models.Role{
+		Name:        payload.Name,
+		Description: payload.Description,
+		Permissions: payload.Permissions,
+	})
+	if err != nil {
+		roleErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusCreated, createdRole); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// UpdateRoleHandler updates a role.
+// @Summary Update a role
+// @Description Replaces the name, description and permissions of a role. Built-in roles cannot be renamed and the admin role always keeps every permission. Requires the roles:manage permission.
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int64 true "Role ID"
+// @Param RolePayload body service_models.RolePayload true "Role"
+// @Success 200 {object} service_models.Role "Updated role"
+// @Failure 400 {object} ErrorResponse "Bad Request or unknown permission"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Role not found"
+// @Failure 409 {object} ErrorResponse "Role already exists or is built in"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/roles/{id} [put]
+func (ro *role) UpdateRoleHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	var payload service_models.RolePayload
+	if err = readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err = Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	updatedRole, err := ro.roleService.UpdateRole(ctx, &service_models.Role{
+		ID:          id,
+		Name:        payload.Name,
+		Description: payload.Description,
+		Permissions: payload.Permissions,
+	})
+	if err != nil {
+		roleErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, updatedRole); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// DeleteRoleHandler deletes a role.
+// @Summary Delete a role
+// @Description Deletes a role and removes it from every user. Built-in roles cannot be deleted. Requires the roles:manage permission.
+// @Tags Roles
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int64 true "Role ID"
+// @Success 200 {string} string "The role was successfully deleted"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Role not found"
+// @Failure 409 {object} ErrorResponse "Role is built in"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/roles/{id} [delete]
+func (ro *role) DeleteRoleHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	if err = ro.roleService.DeleteRole(ctx, id); err != nil {
+		roleErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, "the role was successfully deleted"); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// GetUserRolesHandler shows the roles of a user.
+// @Summary Get user roles
+// @Description Shows the roles assigned to a user. Requires the roles:manage permission.
+// @Tags Roles
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int64 true "User ID"
+// @Success 200 {object} service_models.UserRoles "User roles"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/users/{id}/roles [get]
+func (ro *role) GetUserRolesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	roles, err := ro.roleService.GetUserRoles(ctx, id)
+	if err != nil {
+		roleErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, roles); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// SetUserRolesHandler replaces the roles of a user.
+// @Summary Set user roles
+// @Description Replaces the roles of a user. The change applies to the user's existing sessions right away. The last admin cannot lose the admin role. Requires the roles:manage permission.
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int64 true "User ID"
+// @Param UserRolesPayload body service_models.UserRolesPayload true "Role names"
+// @Success 200 {object} service_models.UserRoles "Updated user roles"
+// @Failure 400 {object} ErrorResponse "Bad Request or unknown role"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 409 {object} ErrorResponse "Last admin"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/users/{id}/roles [put]
+func (ro *role) SetUserRolesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	var payload service_models.UserRolesPayload
+	if err = readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	if err = Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	// The last-admin check and the change run in one transaction, so two
+	// admins demoting each other cannot both pass it.
+	var roles *service_models.UserRoles
+	err = ro.transactor.WithTx(ctx, func(tx *sql.Tx) error {
+		roles, err = ro.roleService.GetWithTXT(tx).SetUserRoles(ctx, id, payload.Roles)
+		return err
+	})
+	if err != nil {
+		roleErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, roles); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+func roleErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrRecordNotFound):
+		notFoundResponse(w, r, err)
+	case errors.Is(err, repository.ErrUnknownRole), errors.Is(err, repository.ErrUnknownPermission):
+		badRequestResponse(w, r, err)
+	case errors.Is(err, repository.ErrDuplicateRole), errors.Is(err, repository.ErrBuiltinRole),
+		errors.Is(err, repository.ErrAdminRoleLocked), errors.Is(err, repository.ErrLastAdmin):
+		conflictResponse(w, r, err)
+	default:
+		internalServerError(w, r, err)
+	}
+}
+
+func NewRoleHandler(roleService service.Role, transactor service.Transactor) *role {
+	return &role{
+		roleService: roleService,
+		transactor:  transactor,
+	}
+}
@@ -1,6 +1,7 @@
 package gateway
 
 import (
+	"database/sql"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
 	"github.com/saleh-ghazimoradi/GoJobs/config"
@@ -8,8 +9,9 @@ import (
 	_ "github.com/saleh-ghazimoradi/GoJobs/docs"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
-	"github.com/saleh-ghazimoradi/GoJobs/logger"
-	"github.com/saleh-ghazimoradi/GoJobs/utils"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"github.com/saleh-ghazimoradi/GoJobs/mailer"
+	"github.com/saleh-ghazimoradi/GoJobs/ratelimit"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"net/http"
 )
@@ -23,52 +25,153 @@ import (
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
-func registerRoutes() http.Handler {
-	db, err := utils.PostConnection()
-	if err != nil {
-		logger.Logger.Error(err.Error())
-	}
-
-	userDB := repository.NewUserRepository(db, db)
-	jobDB := repository.NewJobRepository(db, db)
+// @securityDefinitions.apikey IntegrationKey
+// @in header
+// @name X-API-Key
+func registerRoutes(db, dbRead *sql.DB, replica *repository.ReplicaMonitor, transactor service.Transactor, jobService service.Job, mail mailer.Mailer, limiter ratelimit.Store) http.Handler {
+	userDB := repository.NewUserRepository(db, dbRead)
+	jobDB := repository.NewJobRepository(db, dbRead)
+	applicationDB := repository.NewApplicationRepository(db, dbRead)
+	companyDB := repository.NewCompanyRepository(db, dbRead)
+	tagDB := repository.NewTagRepository(db, dbRead)
+	roleDB := repository.NewRoleRepository(db, dbRead)
 
-	userService := service.NewUserService(userDB)
-	jobService := service.NewJobService(jobDB)
-	authService := service.NewAuthenticateService(userDB)
+	userService := service.NewUserService(userDB, roleDB, transactor)
+	verificationService := service.NewVerificationService(userDB, mail)
+	refreshTokenDB := repository.NewRefreshTokenRepository(db, dbRead)
+	passwordResetDB := repository.NewPasswordResetRepository(db, dbRead)
+	loginDB := repository.NewLoginRepository(db, dbRead)
+	securityEventDB := repository.NewSecurityEventRepository(db, dbRead)
+	apiKeyDB := repository.NewAPIKeyRepository(db, dbRead)
+	identityDB := repository.NewIdentityRepository(db, dbRead)
+	mfaDB := repository.NewMFARepository(db, dbRead)
+	mfaService := service.NewMFAService(mfaDB, userDB)
+	authService := service.NewAuthenticateService(userDB, refreshTokenDB, passwordResetDB, loginDB, securityEventDB, mfaService, mail)
+	applicationService := service.NewApplicationService(applicationDB, jobDB, companyDB)
+	companyService := service.NewCompanyService(companyDB)
+	tagService := service.NewTagService(tagDB)
+	roleService := service.NewRoleService(roleDB)
+	apiKeyService := service.NewAPIKeyService(apiKeyDB, userDB)
+	oidcService := service.NewOIDCService(identityDB, userDB, authService)
 
-	userHandler := NewUserHandler(userService)
+	userHandler := NewUserHandler(userService, verificationService)
 	jobHandler := NewJob(jobService)
-	authHandler := NewAuthenticateHandler(authService)
+	authHandler := NewAuthenticateHandler(authService, verificationService)
+	mfaHandler := NewMFAHandler(mfaService)
+	applicationHandler := NewApplicationHandler(applicationService)
+	companyHandler := NewCompanyHandler(companyService, jobService)
+	tagHandler := NewTagHandler(tagService)
+	roleHandler := NewRoleHandler(roleService, transactor)
+	apiKeyHandler := NewAPIKeyHandler(apiKeyService)
+	oidcHandler := NewOIDCHandler(oidcService)
+
+	limits := config.AppConfig.RateLimit
+	limitGlobal := RateLimitMiddleware(limiter, "global", ratelimit.Limit{Requests: limits.GlobalRequests, Period: limits.GlobalPeriod, Burst: limits.GlobalBurst})
+	limitAuth := RateLimitMiddleware(limiter, "auth", ratelimit.Limit{Requests: limits.AuthRequests, Period: limits.AuthPeriod, Burst: limits.AuthBurst})
+	limitUser := RateLimitMiddleware(limiter, "user", ratelimit.Limit{Requests: limits.UserRequests, Period: limits.UserPeriod, Burst: limits.UserBurst})
+
+	// auth only accepts access tokens; authOrKey also accepts API keys and is
+	// used for the job and application routes integrations need.
+	authenticate := AuthMiddleware(authService, apiKeyService)
+	auth := func(next http.Handler) http.Handler {
+		return authenticate(limitUser(sessionOnly(next)))
+	}
+	authOrKey := func(next http.Handler) http.Handler {
+		return authenticate(limitUser(next))
+	}
+	jobScopes := []string{service_models.PermissionJobsWrite}
+	candidateScopes := []string{service_models.PermissionApplicationsWrite}
+	employerScopes := []string{service_models.PermissionJobsWrite, service_models.PermissionApplicationsManage}
+	applicationScopes := []string{service_models.PermissionApplicationsWrite, service_models.PermissionJobsWrite, service_models.PermissionApplicationsManage}
+	verifiedToPost := VerifiedEmailMiddleware(verificationService, config.AppConfig.Verification.RequiredToPost)
+	verifiedToApply := VerifiedEmailMiddleware(verificationService, config.AppConfig.Verification.RequiredToApply)
 
 	router := httprouter.New()
 
 	router.NotFound = http.HandlerFunc(notFoundRouter)
 	router.MethodNotAllowed = http.HandlerFunc(methodNotAllowedResponse)
 
-	router.HandlerFunc(http.MethodGet, "/v1/healthcheck", healthCheckHandler)
-	router.HandlerFunc(http.MethodPost, "/v1/forgotpassword", authHandler.ForgotPasswordHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/healthcheck", healthCheckHandler(replica))
+	router.HandlerFunc(http.MethodGet, "/.well-known/jwks.json", jwksHandler)
+	router.Handler(http.MethodPost, "/v1/forgotpassword", limitAuth(http.HandlerFunc(authHandler.ForgotPasswordHandler)))
+	router.Handler(http.MethodPost, "/v1/resetpassword", limitAuth(http.HandlerFunc(authHandler.ResetPasswordHandler)))
+	router.Handler(http.MethodPost, "/v1/unlock-account", limitAuth(http.HandlerFunc(authHandler.UnlockAccountHandler)))
 
-	router.HandlerFunc(http.MethodPost, "/v1/login", authHandler.loginHandler)
-	router.HandlerFunc(http.MethodPost, "/v1/register", authHandler.registerHandler)
+	router.Handler(http.MethodPost, "/v1/login", limitAuth(http.HandlerFunc(authHandler.loginHandler)))
+	router.Handler(http.MethodPost, "/v1/login/mfa", limitAuth(http.HandlerFunc(authHandler.loginMFAHandler)))
+	router.Handler(http.MethodGet, "/v1/oidc/login", limitAuth(http.HandlerFunc(oidcHandler.LoginHandler)))
+	router.Handler(http.MethodGet, "/v1/oidc/callback", limitAuth(http.HandlerFunc(oidcHandler.CallbackHandler)))
+	router.Handler(http.MethodPost, "/v1/register", limitAuth(http.HandlerFunc(authHandler.registerHandler)))
+	router.Handler(http.MethodPost, "/v1/token/refresh", limitAuth(http.HandlerFunc(authHandler.refreshTokenHandler)))
+	router.Handler(http.MethodPost, "/v1/logout", limitAuth(http.HandlerFunc(authHandler.logoutHandler)))
+	router.Handler(http.MethodGet, "/v1/verify-email", limitAuth(http.HandlerFunc(authHandler.VerifyEmailHandler)))
+	router.Handler(http.MethodPost, "/v1/verify-email/resend", auth(http.HandlerFunc(authHandler.ResendVerificationHandler)))
 
-	router.Handler(http.MethodGet, "/v1/users/:id", AuthMiddleware(http.HandlerFunc(userHandler.getUserByIdHandler)))
-	router.Handler(http.MethodPut, "/v1/users/:id", AuthMiddleware(http.HandlerFunc(userHandler.UpdateUserProfileHandler)))
-	router.Handler(http.MethodPost, "/v1/users/:id/picture", AuthMiddleware(http.HandlerFunc(userHandler.UpdateUserProfilePictureHandler)))
-	router.Handler(http.MethodGet, "/v1/users", AuthMiddleware(http.HandlerFunc(userHandler.GetAllUsersHandler)))
-	router.Handler(http.MethodDelete, "/v1/users/:id", AuthMiddleware(http.HandlerFunc(userHandler.DeleteUserHandler)))
-	router.Handler(http.MethodPut, "/v1/users/:id/changePassword", AuthMiddleware(http.HandlerFunc(userHandler.ChangePasswordHandler)))
+	router.Handler(http.MethodGet, "/v1/mfa", auth(http.HandlerFunc(mfaHandler.GetMFAStatusHandler)))
+	router.Handler(http.MethodPost, "/v1/mfa/totp", auth(http.HandlerFunc(mfaHandler.BeginTOTPEnrollmentHandler)))
+	router.Handler(http.MethodPost, "/v1/mfa/totp/confirm", auth(http.HandlerFunc(mfaHandler.ConfirmTOTPEnrollmentHandler)))
+	router.Handler(http.MethodDelete, "/v1/mfa/totp", auth(http.HandlerFunc(mfaHandler.DisableTOTPHandler)))
+	router.Handler(http.MethodPost, "/v1/mfa/recovery-codes", auth(http.HandlerFunc(mfaHandler.RegenerateRecoveryCodesHandler)))
+	router.Handler(http.MethodGet, "/v1/admin/mfa-policy", auth(requirePermission(service_models.PermissionSecurityManage, http.HandlerFunc(mfaHandler.GetMFAPolicyHandler))))
+	router.Handler(http.MethodPut, "/v1/admin/mfa-policy", auth(requirePermission(service_models.PermissionSecurityManage, http.HandlerFunc(mfaHandler.UpdateMFAPolicyHandler))))
+
+	router.Handler(http.MethodGet, "/v1/users/:id", auth(http.HandlerFunc(userHandler.getUserByIdHandler)))
+	router.Handler(http.MethodPut, "/v1/users/:id", auth(http.HandlerFunc(userHandler.UpdateUserProfileHandler)))
+	router.Handler(http.MethodPost, "/v1/users/:id/picture", auth(http.HandlerFunc(userHandler.UpdateUserProfilePictureHandler)))
+	router.Handler(http.MethodGet, "/v1/users", auth(requirePermission(service_models.PermissionUsersRead, http.HandlerFunc(userHandler.GetAllUsersHandler))))
+	router.Handler(http.MethodDelete, "/v1/users/:id", auth(requirePermission(service_models.PermissionUsersWrite, http.HandlerFunc(userHandler.DeleteUserHandler))))
+	router.Handler(http.MethodGet, "/v1/users/:id/roles", auth(requirePermission(service_models.PermissionRolesManage, http.HandlerFunc(roleHandler.GetUserRolesHandler))))
+	router.Handler(http.MethodPut, "/v1/users/:id/roles", auth(requirePermission(service_models.PermissionRolesManage, http.HandlerFunc(roleHandler.SetUserRolesHandler))))
+	router.Handler(http.MethodPut, "/v1/users/:id/changePassword", auth(http.HandlerFunc(userHandler.ChangePasswordHandler)))
 
 	router.HandlerFunc(http.MethodGet, "/v1/jobs", jobHandler.GetAllJobsHandler)
-	router.Handler(http.MethodPost, "/v1/jobs", AuthMiddleware(http.HandlerFunc(jobHandler.CreateJobHandler)))
-	router.Handler(http.MethodGet, "/v1/jobsByUser", AuthMiddleware(http.HandlerFunc(jobHandler.GetAllJobsHandler)))
-	router.Handler(http.MethodGet, "/v1/jobs/:id", AuthMiddleware(http.HandlerFunc(jobHandler.GetJobByIdHandler)))
-	router.Handler(http.MethodPut, "/v1/jobs/:id", AuthMiddleware(http.HandlerFunc(jobHandler.UpdateJobHandler)))
-	router.Handler(http.MethodDelete, "/v1/jobs/:id", AuthMiddleware(http.HandlerFunc(jobHandler.DeleteJobHandler)))
+	router.HandlerFunc(http.MethodGet, "/v1/search/jobs", jobHandler.SearchJobsHandler)
+	router.Handler(http.MethodPost, "/v1/jobs", authOrKey(requirePermission(service_models.PermissionJobsWrite, verifiedToPost(http.HandlerFunc(jobHandler.CreateJobHandler)))))
+	router.Handler(http.MethodGet, "/v1/jobsByUser", authOrKey(requireScope(jobScopes, http.HandlerFunc(jobHandler.GetAllJobsByUserHandler))))
+	router.Handler(http.MethodGet, "/v1/jobs/:id", authOrKey(requireScope(jobScopes, http.HandlerFunc(jobHandler.GetJobByIdHandler))))
+	router.Handler(http.MethodPut, "/v1/jobs/:id", authOrKey(requireScope(jobScopes, http.HandlerFunc(jobHandler.UpdateJobHandler))))
+	router.Handler(http.MethodDelete, "/v1/jobs/:id", authOrKey(requireScope(jobScopes, http.HandlerFunc(jobHandler.DeleteJobHandler))))
+	router.Handler(http.MethodPost, "/v1/jobs/:id/publish", authOrKey(requireScope(jobScopes, http.HandlerFunc(jobHandler.PublishJobHandler))))
+	router.Handler(http.MethodPost, "/v1/jobs/:id/close", authOrKey(requireScope(jobScopes, http.HandlerFunc(jobHandler.CloseJobHandler))))
+
+	router.Handler(http.MethodPost, "/v1/jobs/:id/applications", authOrKey(requirePermission(service_models.PermissionApplicationsWrite, verifiedToApply(http.HandlerFunc(applicationHandler.ApplyToJobHandler)))))
+	router.Handler(http.MethodGet, "/v1/jobs/:id/applications", authOrKey(requireScope(employerScopes, http.HandlerFunc(applicationHandler.GetAllApplicationsByJobHandler))))
+	router.Handler(http.MethodGet, "/v1/applications", authOrKey(requireScope(candidateScopes, http.HandlerFunc(applicationHandler.GetMyApplicationsHandler))))
+	router.Handler(http.MethodGet, "/v1/applications/:id", authOrKey(requireScope(applicationScopes, http.HandlerFunc(applicationHandler.GetApplicationByIdHandler))))
+	router.Handler(http.MethodGet, "/v1/applications/:id/resume", authOrKey(requireScope(applicationScopes, http.HandlerFunc(applicationHandler.GetApplicationResumeHandler))))
+	router.Handler(http.MethodPatch, "/v1/applications/:id/stage", authOrKey(requireScope(employerScopes, http.HandlerFunc(applicationHandler.ChangeStageHandler))))
+	router.Handler(http.MethodGet, "/v1/applications/:id/history", authOrKey(requireScope(applicationScopes, http.HandlerFunc(applicationHandler.GetApplicationHistoryHandler))))
+
+	router.HandlerFunc(http.MethodGet, "/v1/companies", companyHandler.GetAllCompaniesHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/companies/:id", companyHandler.GetCompanyHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/companies/:id/jobs", companyHandler.GetCompanyJobsHandler)
+	router.Handler(http.MethodPost, "/v1/companies", auth(requirePermission(service_models.PermissionJobsWrite, http.HandlerFunc(companyHandler.CreateCompanyHandler))))
+	router.Handler(http.MethodPut, "/v1/companies/:id", auth(http.HandlerFunc(companyHandler.UpdateCompanyHandler)))
+	router.Handler(http.MethodDelete, "/v1/companies/:id", auth(http.HandlerFunc(companyHandler.DeleteCompanyHandler)))
+	router.Handler(http.MethodGet, "/v1/companies/:id/members", auth(http.HandlerFunc(companyHandler.GetCompanyMembersHandler)))
+	router.Handler(http.MethodPut, "/v1/companies/:id/members/:user_id", auth(http.HandlerFunc(companyHandler.SetCompanyMemberHandler)))
+	router.Handler(http.MethodDelete, "/v1/companies/:id/members/:user_id", auth(http.HandlerFunc(companyHandler.RemoveCompanyMemberHandler)))
+
+	router.HandlerFunc(http.MethodGet, "/v1/tags", tagHandler.GetAllTagsHandler)
+	router.Handler(http.MethodPost, "/v1/tags", authOrKey(requirePermission(service_models.PermissionTagsManage, http.HandlerFunc(tagHandler.CreateTagHandler))))
+	router.Handler(http.MethodPut, "/v1/tags/:id", authOrKey(requirePermission(service_models.PermissionTagsManage, http.HandlerFunc(tagHandler.UpdateTagHandler))))
+	router.Handler(http.MethodDelete, "/v1/tags/:id", authOrKey(requirePermission(service_models.PermissionTagsManage, http.HandlerFunc(tagHandler.DeleteTagHandler))))
+
+	router.Handler(http.MethodGet, "/v1/api-keys", auth(http.HandlerFunc(apiKeyHandler.GetAllAPIKeysHandler)))
+	router.Handler(http.MethodPost, "/v1/api-keys", auth(http.HandlerFunc(apiKeyHandler.CreateAPIKeyHandler)))
+	router.Handler(http.MethodDelete, "/v1/api-keys/:id", auth(http.HandlerFunc(apiKeyHandler.RevokeAPIKeyHandler)))
+
+	router.Handler(http.MethodGet, "/v1/permissions", auth(requirePermission(service_models.PermissionRolesManage, http.HandlerFunc(roleHandler.GetAllPermissionsHandler))))
+	router.Handler(http.MethodGet, "/v1/roles", auth(requirePermission(service_models.PermissionRolesManage, http.HandlerFunc(roleHandler.GetAllRolesHandler))))
+	router.Handler(http.MethodPost, "/v1/roles", auth(requirePermission(service_models.PermissionRolesManage, http.HandlerFunc(roleHandler.CreateRoleHandler))))
+	router.Handler(http.MethodGet, "/v1/roles/:id", auth(requirePermission(service_models.PermissionRolesManage, http.HandlerFunc(roleHandler.GetRoleHandler))))
+	router.Handler(http.MethodPut, "/v1/roles/:id", auth(requirePermission(service_models.PermissionRolesManage, http.HandlerFunc(roleHandler.UpdateRoleHandler))))
+	router.Handler(http.MethodDelete, "/v1/roles/:id", auth(requirePermission(service_models.PermissionRolesManage, http.HandlerFunc(roleHandler.DeleteRoleHandler))))
 
 	swaggerHandler := SetupSwagger()
 	router.Handler(http.MethodGet, "/swagger/*any", swaggerHandler)
 
-	return recoverPanic(router)
+	return recoverPanic(limitGlobal(pinPrimaryAfterWrite(router)))
 }
 
 // SetupSwagger
@@ -2,9 +2,17 @@ package gateway
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
 	"github.com/saleh-ghazimoradi/GoJobs/logger"
+	"github.com/saleh-ghazimoradi/GoJobs/mailer"
+	"github.com/saleh-ghazimoradi/GoJobs/ratelimit"
+	"github.com/saleh-ghazimoradi/GoJobs/scripts/migrations"
+	"github.com/saleh-ghazimoradi/GoJobs/utils"
 	"net/http"
 	"os"
 	"os/signal"
@@ -16,7 +24,44 @@ import (
 var wg sync.WaitGroup
 
 func Server() error {
-	router := registerRoutes()
+	db, err := utils.PostConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if config.AppConfig.DBConfig.AutoMigrate {
+		if err = autoMigrate(db); err != nil {
+			return err
+		}
+	}
+
+	dbRead, replica, err := openReadReplica(db)
+	if err != nil {
+		return err
+	}
+	if dbRead != db {
+		defer dbRead.Close()
+	}
+
+	if err = utils.LoadJWTKeys(); err != nil {
+		return err
+	}
+
+	transactor := service.NewTransactor(db)
+	jobService := service.NewJobService(repository.NewJobRepository(db, dbRead), repository.NewCompanyRepository(db, dbRead), repository.NewTagRepository(db, dbRead), transactor)
+
+	mail, err := mailer.New(config.AppConfig.Mailer)
+	if err != nil {
+		return err
+	}
+
+	limiter, err := ratelimit.New(config.AppConfig.RateLimit)
+	if err != nil {
+		return err
+	}
+
+	router := registerRoutes(db, dbRead, replica, transactor, jobService, mail, limiter)
 	srv := &http.Server{
 		Addr:         config.AppConfig.ServerConfig.Port,
 		Handler:      router,
@@ -27,6 +72,13 @@ func Server() error {
 
 	shutdownError := make(chan error)
 
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	startJobSweeper(workerCtx, jobService)
+	startRateLimitSweeper(workerCtx, limiter)
+	startJWTKeyReloader(workerCtx)
+	startReplicaMonitor(workerCtx, replica)
+
 	go func() {
 		quit := make(chan os.Signal, 1)
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -44,13 +96,21 @@ func Server() error {
 
 		logger.Logger.Info("completing background tasks", "addr", srv.Addr)
 
+		stopWorkers()
+
 		wg.Wait()
+
+		logger.Logger.Info("flushing mail queue")
+		if err = mail.Shutdown(ctx); err != nil {
+			logger.Logger.Error("mail queue not flushed", "error", err.Error())
+		}
+
 		shutdownError <- nil
 	}()
 
 	logger.Logger.Info("starting server", "addr", config.AppConfig.ServerConfig.Port, "env", config.AppConfig.ServerConfig.Version)
 
-	err := srv.ListenAndServe()
+	err = srv.ListenAndServe()
 	if !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
@@ -64,3 +124,44 @@ func Server() error {
 
 	return nil
 }
+
+// openReadReplica returns the pool that repositories read from: the replica
+// with its monitor when one is configured, and the primary otherwise.
+func openReadReplica(primary *sql.DB) (*sql.DB, *repository.ReplicaMonitor, error) {
+	replicaDB, err := utils.ReplicaConnection()
+	if err != nil {
+		return nil, nil, err
+	}
+	if replicaDB == nil {
+		return primary, nil, nil
+	}
+
+	replica := repository.NewReplicaMonitor(replicaDB, config.AppConfig.DBConfig.ReplicaMaxLag)
+	ctx, cancel := context.WithTimeout(context.Background(), config.AppConfig.DBConfig.Timeout)
+	defer cancel()
+	replica.Check(ctx)
+
+	return replicaDB, replica, nil
+}
+
+// autoMigrate applies pending migrations before the server starts. Instances
+// starting at the same time wait for each other on the migration lock.
+func autoMigrate(db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.AppConfig.DBConfig.Timeout)
+	defer cancel()
+
+	migrator, err := migrations.New(ctx, db)
+	if err != nil {
+		return fmt.Errorf("auto-migrate: %w", err)
+	}
+	defer migrator.Close()
+
+	applied, err := migrator.Up(0)
+	if err != nil {
+		return fmt.Errorf("auto-migrate: %w", err)
+	}
+	if applied {
+		logger.Logger.Info("applied pending migrations")
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"net/http"
+	"time"
+)
+
+type tag struct {
+	tagService service.Tag
+}
+
+// GetAllTagsHandler lists every tag with its usage count.
+// @Summary List tags
+// @Description Lists every tag with the number of open jobs carrying it, most used first.
+// @Tags Tags
+// @Produce json
+// @Success 200 {array} service_models.Tag "Tags with usage counts"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/tags [get]
+func (t *tag) GetAllTagsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tags, err := t.tagService.GetAllTags(ctx)
+	if err != nil {
+		internalServerError(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, tags); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// CreateTagHandler adds a tag to the taxonomy.
+// @Summary Create a tag
+// @Description Adds a tag that jobs can be labelled with. Names are stored in lowercase. Requires the tags:manage permission.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Tag body service_models.Tag true "Tag name"
+// @Success 201 {object} service_models.Tag "Tag created"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 409 {object} ErrorResponse "Tag already exists"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/tags [post]
+func (t *tag) CreateTagHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var payload service_models.Tag
+	if err := readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	payload.Name = service_models.NormalizeTag(payload.Name)
+
+	if err := Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	createdTag, err := t.tagService.CreateTag(ctx, &payload)
+	if err != nil {
+		tagErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusCreated, createdTag); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// UpdateTagHandler renames a tag.
+// @Summary Rename a tag
+// @Description Renames a tag. Jobs carrying the tag keep it under its new name. Requires the tags:manage permission.
+// @Tags Tags
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int64 true "Tag ID"
+// @Param Tag body service_models.Tag true "New tag name"
+// @Success 200 {object} service_models.Tag "Updated tag"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Tag not found"
+// @Failure 409 {object} ErrorResponse "Tag already exists"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/tags/{id} [put]
+func (t *tag) UpdateTagHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	var payload service_models.Tag
+	if err = readJSON(w, r, &payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+	payload.ID = id
+	payload.Name = service_models.NormalizeTag(payload.Name)
+
+	if err = Validate.Struct(payload); err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	updatedTag, err := t.tagService.UpdateTag(ctx, &payload)
+	if err != nil {
+		tagErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, updatedTag); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+// DeleteTagHandler removes a tag.
+// @Summary Delete a tag
+// @Description Removes a tag from the taxonomy and from every job carrying it. Requires the tags:manage permission.
+// @Tags Tags
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int64 true "Tag ID"
+// @Success 200 {string} string "The tag was successfully deleted"
+// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Tag not found"
+// @Failure 500 {object} ErrorResponse "Internal Server Error"
+// @Router /v1/tags/{id} [delete]
+func (t *tag) DeleteTagHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := readIDParam(r)
+	if err != nil {
+		badRequestResponse(w, r, err)
+		return
+	}
+
+	if err = t.tagService.DeleteTag(ctx, id); err != nil {
+		tagErrorResponse(w, r, err)
+		return
+	}
+
+	if err = jsonResponse(w, http.StatusOK, "the tag was successfully deleted"); err != nil {
+		internalServerError(w, r, err)
+	}
+}
+
+func tagErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repository.ErrRecordNotFound):
+		notFoundResponse(w, r, err)
+	case errors.Is(err, repository.ErrDuplicateTag):
+		conflictResponse(w, r, err)
+	default:
+		internalServerError(w, r, err)
+	}
+}
+
+func NewTagHandler(tagService service.Tag) *tag {
+	return &tag{
+		tagService: tagService,
+	}
+}
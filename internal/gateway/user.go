@@ -9,6 +9,7 @@ import (
 	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"github.com/saleh-ghazimoradi/GoJobs/logger"
 	"io"
 	"net/http"
 	"os"
@@ -17,7 +18,8 @@ import (
 )
 
 type user struct {
-	userService service.User
+	userService         service.User
+	verificationService service.EmailVerification
 }
 
 // getUserByIdHandler retrieves a user by ID.
@@ -34,7 +36,7 @@ type user struct {
 // @Failure 500 {object} ErrorResponse
 // @Router /v1/users/{id} [get]
 func (u *user) getUserByIdHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
 	defer cancel()
 	id, err := readIDParam(r)
 	if err != nil {
@@ -59,35 +61,40 @@ func (u *user) getUserByIdHandler(w http.ResponseWriter, r *http.Request) {
 
 // UpdateUserProfileHandler updates the profile of a user by ID.
 // @Summary Update user profile
-// @Description Update the user profile (username and email). Requires authorization token and admin check.
+// @Description Update the user profile (username and email). Users can update their own profile; updating others requires the users:write permission. Changing the email marks it unverified and emails a verification link to the new address.
 // @Tags Users
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path int true "User ID"
 // @Param updateUser body service_models.UpdateUserPayload true "User Profile Information"
+// @Param Accept-Language header string false "Preferred language of the verification email"
 // @Success 200 {object} service_models.User
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /v1/users/{id} [put]
 func (u *user) UpdateUserProfileHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
 	defer cancel()
 	id, err := readIDParam(r)
 	if err != nil {
 		badRequestResponse(w, r, err)
+		return
 	}
 
 	var updateUser service_models.UpdateUserPayload
 
 	if err = readJSON(w, r, &updateUser); err != nil {
 		badRequestResponse(w, r, err)
+		return
 	}
 
 	if err = Validate.Struct(updateUser); err != nil {
 		badRequestResponse(w, r, err)
+		return
 	}
 
 	userID, ok := r.Context().Value("userID").(int64)
@@ -96,25 +103,31 @@ func (u *user) UpdateUserProfileHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	isAdmin, ok := r.Context().Value("isAdmin").(bool)
-	if !ok {
+	if !hasPermission(r, service_models.PermissionUsersWrite) && userID != id {
 		unauthorizedErrorResponse(w, r, fmt.Errorf("unauthorized to update this user profile"))
 		return
 	}
 
-	if !isAdmin && userID != id {
-		unauthorizedErrorResponse(w, r, fmt.Errorf("unauthorized to update this user profile"))
-	}
-
 	updateUse, err := u.userService.UpdateUserProfile(ctx, id, updateUser.Username, updateUser.Email)
 	if err != nil {
 		switch {
-		case errors.Is(err, sql.ErrNoRows):
+		case errors.Is(err, repository.ErrRecordNotFound):
 			notFoundResponse(w, r, err)
+		case errors.Is(err, repository.ErrDuplicateEmails), errors.Is(err, repository.ErrDuplicateUsernames):
+			conflictResponse(w, r, err)
 		default:
 			internalServerError(w, r, err)
 		}
+		return
+	}
+
+	// A changed email comes back with no verification state at all.
+	if updateUse.EmailVerifiedAt == nil && updateUse.VerificationSentAt == nil {
+		if err = u.verificationService.SendVerification(ctx, updateUse, r.Header.Get("Accept-Language")); err != nil {
+			logger.Logger.Error("failed to send verification email", "user_id", updateUse.ID, "error", err.Error())
+		}
 	}
+
 	if err = jsonResponse(w, http.StatusOK, updateUse); err != nil {
 		internalServerError(w, r, err)
 	}
@@ -122,7 +135,7 @@ func (u *user) UpdateUserProfileHandler(w http.ResponseWriter, r *http.Request)
 
 // UpdateUserProfilePictureHandler updates the profile picture of a user by ID.
 // @Summary Update user profile picture
-// @Description Update the user's profile picture. Requires authorization token and admin check.
+// @Description Update the user's profile picture. Users can update their own picture; updating others requires the users:write permission.
 // @Tags Users
 // @Accept multipart/form-data
 // @Produce json
@@ -135,7 +148,7 @@ func (u *user) UpdateUserProfileHandler(w http.ResponseWriter, r *http.Request)
 // @Failure 500 {object} ErrorResponse
 // @Router /v1/users/{id}/picture [put]
 func (u *user) UpdateUserProfilePictureHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
 	defer cancel()
 	id, err := readIDParam(r)
 	if err != nil {
@@ -148,16 +161,11 @@ func (u *user) UpdateUserProfilePictureHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	isAdmin, ok := r.Context().Value("isAdmin").(bool)
-	if !ok {
+	if !hasPermission(r, service_models.PermissionUsersWrite) && userID != id {
 		unauthorizedErrorResponse(w, r, fmt.Errorf("unauthorized to update this user profile"))
 		return
 	}
 
-	if !isAdmin && userID != id {
-		unauthorizedErrorResponse(w, r, fmt.Errorf("unauthorized to update this user profile"))
-	}
-
 	err = r.ParseMultipartForm(10 << 20)
 	if err != nil {
 		badRequestResponse(w, r, err)
@@ -203,7 +211,7 @@ func (u *user) UpdateUserProfilePictureHandler(w http.ResponseWriter, r *http.Re
 
 // GetAllUsersHandler handles the retrieval of all users.
 // @Summary Retrieve all users
-// @Description Get a list of all users. Requires an authorization token.
+// @Description Get a list of all users. Requires the users:read permission.
 // @Tags Users
 // @Accept json
 // @Produce json
@@ -211,22 +219,12 @@ func (u *user) UpdateUserProfilePictureHandler(w http.ResponseWriter, r *http.Re
 // @Success 200 {array} service_models.User
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /v1/users [get]
 func (u *user) GetAllUsersHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
 	defer cancel()
-	isAdmin, ok := r.Context().Value("isAdmin").(bool)
-	if !ok {
-		unauthorizedErrorResponse(w, r, fmt.Errorf("unauthorized to get all users"))
-		return
-	}
-
-	if !isAdmin {
-		unauthorizedErrorResponse(w, r, fmt.Errorf("unauthorized to get all users"))
-		return
-	}
-
 	users, err := u.userService.GetAllUsers(ctx)
 	if err != nil {
 		internalServerError(w, r, err)
@@ -240,7 +238,7 @@ func (u *user) GetAllUsersHandler(w http.ResponseWriter, r *http.Request) {
 
 // DeleteUserHandler deletes a user by ID.
 // @Summary Delete user
-// @Description Deletes a user by ID. Only an admin user can delete another user. You cannot delete yourself.
+// @Description Deletes a user by ID. Requires the users:write permission. You cannot delete yourself or the last admin.
 // @Tags Users
 // @Accept json
 // @Produce json
@@ -249,18 +247,14 @@ func (u *user) GetAllUsersHandler(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {string} string "User deleted"
 // @Failure 400 {object} ErrorResponse "Bad Request"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 409 {object} ErrorResponse "The user is the last admin"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /v1/users/{id} [delete]
 func (u *user) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
 	defer cancel()
 
-	isAdmin, ok := r.Context().Value("isAdmin").(bool)
-	if !ok || !isAdmin {
-		unauthorizedErrorResponse(w, r, fmt.Errorf("unauthorized to delete user"))
-		return
-	}
-
 	id, err := readIDParam(r)
 	if err != nil {
 		badRequestResponse(w, r, err)
@@ -279,6 +273,10 @@ func (u *user) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 			badRequestResponse(w, r, fmt.Errorf("user not found"))
 			return
 		}
+		if errors.Is(err, repository.ErrLastAdmin) {
+			conflictResponse(w, r, err)
+			return
+		}
 		internalServerError(w, r, err)
 		return
 	}
@@ -291,20 +289,20 @@ func (u *user) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
 
 // ChangePasswordHandler changes the user's password.
 // @Summary Change password
-// @Description Changes the password for the authenticated user. The user must provide their current password and the new password.
+// @Description Changes the password for the authenticated user. The user must provide their current password and the new password. Every session of the user is ended, so they must log in again.
 // @Tags Users
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param ChangePassword body service_models.ChangePassword true "Change Password Request"
 // @Success 200 {string} string "Password successfully changed"
-// @Failure 400 {object} ErrorResponse "Bad Request"
+// @Failure 400 {object} ErrorResponse "Bad Request or incorrect current password"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
 // @Failure 404 {object} ErrorResponse "User not found"
 // @Failure 500 {object} ErrorResponse "Internal Server Error"
 // @Router /v1/users/{id}/changePassword [put]
 func (u *user) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*5)
 	defer cancel()
 	var req service_models.ChangePassword
 	if err := readJSON(w, r, &req); err != nil {
@@ -328,6 +326,9 @@ func (u *user) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, repository.ErrRecordNotFound):
 			notFoundResponse(w, r, err)
 			return
+		case errors.Is(err, repository.ErrIncorrectPassword):
+			badRequestResponse(w, r, err)
+			return
 		default:
 			internalServerError(w, r, err)
 			return
@@ -339,8 +340,9 @@ func (u *user) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func NewUserHandler(userService service.User) *user {
+func NewUserHandler(userService service.User, verificationService service.EmailVerification) *user {
 	return &user{
-		userService: userService,
+		userService:         userService,
+		verificationService: verificationService,
 	}
 }
@@ -0,0 +1,158 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service"
+	"github.com/saleh-ghazimoradi/GoJobs/logger"
+	"github.com/saleh-ghazimoradi/GoJobs/ratelimit"
+	"github.com/saleh-ghazimoradi/GoJobs/utils"
+	"time"
+)
+
+// background runs fn in a goroutine tracked by wg, so that Server waits for it
+// on shutdown, and recovers any panic it raises.
+func background(fn func()) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			if err := recover(); err != nil {
+				logger.Logger.Error("background task panicked", "error", fmt.Sprint(err))
+			}
+		}()
+		fn()
+	}()
+}
+
+// startJobSweeper periodically expires and archives stale job postings until
+// ctx is cancelled. Replicas coordinate through an advisory lock, so it is safe
+// to run on every instance.
+func startJobSweeper(ctx context.Context, jobService service.Job) {
+	interval := config.AppConfig.Scheduler.JobSweepInterval
+	if interval <= 0 {
+		logger.Logger.Info("job sweeper disabled")
+		return
+	}
+
+	background(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			sweepJobs(ctx, jobService)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+func sweepJobs(ctx context.Context, jobService service.Job) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	result, err := jobService.SweepJobs(ctx, config.AppConfig.Scheduler.JobRetention)
+	if err != nil {
+		logger.Logger.Error("job sweep failed", "error", err.Error())
+		return
+	}
+	if !result.Acquired {
+		logger.Logger.Debug("job sweep skipped, another instance holds the lock")
+		return
+	}
+	if result.Expired > 0 || result.Archived > 0 {
+		logger.Logger.Info("job sweep completed", "expired", result.Expired, "archived", result.Archived)
+	}
+}
+
+// startRateLimitSweeper periodically drops idle buckets from stores that do not
+// expire them on their own until ctx is cancelled.
+func startRateLimitSweeper(ctx context.Context, store ratelimit.Store) {
+	sweeper, ok := store.(ratelimit.Sweeper)
+	interval := config.AppConfig.RateLimit.SweepInterval
+	if !ok || interval <= 0 {
+		return
+	}
+
+	background(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				sweeper.Sweep(now)
+			}
+		}
+	})
+}
+
+// startJWTKeyReloader periodically re-reads the JWT key directory until ctx is
+// cancelled, so that keys added or removed by `GoJobs keys rotate` are picked
+// up without a restart. A failed reload keeps the current keys.
+func startJWTKeyReloader(ctx context.Context) {
+	interval := config.AppConfig.JWT.ReloadInterval
+	if interval <= 0 {
+		return
+	}
+
+	background(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := utils.LoadJWTKeys(); err != nil {
+					logger.Logger.Error("reloading JWT keys failed", "error", err.Error())
+				}
+			}
+		}
+	})
+}
+
+// startReplicaMonitor periodically checks the read replica's health and lag
+// until ctx is cancelled, taking it out of rotation while it is behind.
+func startReplicaMonitor(ctx context.Context, replica *repository.ReplicaMonitor) {
+	interval := config.AppConfig.DBConfig.ReplicaCheckInterval
+	if replica == nil || interval <= 0 {
+		return
+	}
+
+	background(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		wasHealthy := replica.Status().Healthy
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			checkCtx, cancel := context.WithTimeout(ctx, interval)
+			status := replica.Check(checkCtx)
+			cancel()
+
+			if status.Healthy != wasHealthy {
+				if status.Healthy {
+					logger.Logger.Info("read replica is back in rotation", "lag_seconds", status.Lag)
+				} else {
+					logger.Logger.Warn("read replica taken out of rotation", "lag_seconds", status.Lag, "error", status.Error)
+				}
+				wasHealthy = status.Healthy
+			}
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+)
+
+type APIKey interface {
+	CreateAPIKey(ctx context.Context, key *service_models.APIKey, hash []byte, maxActive int) error
+	GetAllAPIKeys(ctx context.Context, userID int64) ([]*service_models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash []byte) (*service_models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id int64) error
+	RevokeAPIKey(ctx context.Context, id, userID int64) error
+	GetWithTXT(tx *sql.Tx) APIKey
+}
+
+type apiKeyRepository struct {
+	dbWrite *sql.DB
+	dbRead  *sql.DB
+	tx      *sql.Tx
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+// CreateAPIKey stores a key unless the user already has maxActive keys that
+// are neither revoked nor expired, in which case it returns ErrTooManyAPIKeys.
+func (a *apiKeyRepository) CreateAPIKey(ctx context.Context, key *service_models.APIKey, hash []byte, maxActive int) error {
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		SELECT $1, $2, $3, $4, $5, $6
+		WHERE (
+			SELECT COUNT(*) FROM api_keys
+			WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		) < $7
+		RETURNING id, created_at`
+	err := a.write(ctx).QueryRowContext(ctx, query, key.UserID, key.Name, key.Prefix, hash, pq.Array(key.Scopes), key.ExpiresAt, maxActive).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrTooManyAPIKeys
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *apiKeyRepository) GetAllAPIKeys(ctx context.Context, userID int64) ([]*service_models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC, id DESC`
+	rows, err := a.read(ctx).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*service_models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// GetAPIKeyByHash reads from the primary so a revoked key stops working right
+// away.
+func (a *apiKeyRepository) GetAPIKeyByHash(ctx context.Context, hash []byte) (*service_models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+	key, err := scanAPIKey(a.primary().QueryRowContext(ctx, query, hash))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// TouchAPIKey records that a key was used. It only writes once a minute per
+// key, so busy integrations do not turn every request into an update, and only
+// pins the request to the primary when it did write.
+func (a *apiKeyRepository) TouchAPIKey(ctx context.Context, id int64) error {
+	query := `
+		UPDATE api_keys SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+	res, err := a.primary().ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows > 0 {
+		pinToPrimary(ctx)
+	}
+	return nil
+}
+
+// RevokeAPIKey revokes a key of userID. Keys of other users and keys that are
+// already revoked are reported as not found.
+func (a *apiKeyRepository) RevokeAPIKey(ctx context.Context, id, userID int64) error {
+	query := `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+	res, err := a.write(ctx).ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func scanAPIKey(row rowScanner) (*service_models.APIKey, error) {
+	var key service_models.APIKey
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (a *apiKeyRepository) write(ctx context.Context) Querier {
+	return writeQuerier(ctx, a.dbWrite, a.tx)
+}
+
+func (a *apiKeyRepository) primary() Querier {
+	return primaryQuerier(a.dbWrite, a.tx)
+}
+
+func (a *apiKeyRepository) read(ctx context.Context) Querier {
+	return readQuerier(ctx, a.dbWrite, a.dbRead, a.tx)
+}
+
+func (a *apiKeyRepository) GetWithTXT(tx *sql.Tx) APIKey {
+	return &apiKeyRepository{
+		dbWrite: a.dbWrite,
+		dbRead:  a.dbRead,
+		tx:      tx,
+	}
+}
+
+func NewAPIKeyRepository(dbWrite *sql.DB, dbRead *sql.DB) APIKey {
+	return &apiKeyRepository{
+		dbWrite: dbWrite,
+		dbRead:  dbRead,
+	}
+}
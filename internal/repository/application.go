@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+)
+
+type Application interface {
+	CreateApplication(ctx context.Context, application *service_models.Application) (*service_models.Application, error)
+	GetApplicationById(ctx context.Context, id int64) (*service_models.Application, error)
+	GetAllApplicationsByJobID(ctx context.Context, jobID int64) ([]*service_models.Application, error)
+	GetAllApplicationsByUserID(ctx context.Context, userID int64) ([]*service_models.Application, error)
+	UpdateApplicationStage(ctx context.Context, id int64, from, to service_models.ApplicationStage, actorID int64, note string) (*service_models.ApplicationHistory, error)
+	GetApplicationHistory(ctx context.Context, applicationID int64) ([]*service_models.ApplicationHistory, error)
+	GetWithTXT(tx *sql.Tx) Application
+}
+
+const applicationColumns = `id, job_id, user_id, cover_letter, resume, stage, created_at, updated_at`
+
+type applicationRepository struct {
+	dbWrite *sql.DB
+	dbRead  *sql.DB
+	tx      *sql.Tx
+}
+
+func (a *applicationRepository) CreateApplication(ctx context.Context, application *service_models.Application) (*service_models.Application, error) {
+	query := `
+		WITH inserted AS (
+			INSERT INTO applications (job_id, user_id, cover_letter, resume) VALUES ($1, $2, $3, $4)
+			RETURNING id, user_id, stage, created_at, updated_at
+		), history AS (
+			INSERT INTO application_history (application_id, to_stage, actor_id)
+			SELECT id, stage, user_id FROM inserted
+		)
+		SELECT id, stage, created_at, updated_at FROM inserted`
+	err := a.write(ctx).QueryRowContext(ctx, query, application.JobID, application.UserID, application.CoverLetter, application.Resume).Scan(&application.ID, &application.Stage, &application.CreatedAt, &application.UpdatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "applications_job_id_user_id_key"`:
+			return nil, ErrDuplicateApplication
+		default:
+			return nil, err
+		}
+	}
+	return application, nil
+}
+
+func (a *applicationRepository) GetApplicationById(ctx context.Context, id int64) (*service_models.Application, error) {
+	query := fmt.Sprintf(`SELECT %s FROM applications WHERE id = $1`, applicationColumns)
+	application, err := scanApplication(a.read(ctx).QueryRowContext(ctx, query, id))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return application, nil
+}
+
+func (a *applicationRepository) GetAllApplicationsByJobID(ctx context.Context, jobID int64) ([]*service_models.Application, error) {
+	query := fmt.Sprintf(`SELECT %s FROM applications WHERE job_id = $1 ORDER BY created_at, id`, applicationColumns)
+	return a.queryApplications(ctx, query, jobID)
+}
+
+func (a *applicationRepository) GetAllApplicationsByUserID(ctx context.Context, userID int64) ([]*service_models.Application, error) {
+	query := fmt.Sprintf(`SELECT %s FROM applications WHERE user_id = $1 ORDER BY created_at DESC, id DESC`, applicationColumns)
+	return a.queryApplications(ctx, query, userID)
+}
+
+func (a *applicationRepository) UpdateApplicationStage(ctx context.Context, id int64, from, to service_models.ApplicationStage, actorID int64, note string) (*service_models.ApplicationHistory, error) {
+	query := `
+		WITH updated AS (
+			UPDATE applications SET stage = $1, updated_at = NOW() WHERE id = $2 AND stage = $3
+			RETURNING id
+		)
+		INSERT INTO application_history (application_id, from_stage, to_stage, actor_id, note)
+		SELECT id, $3, $1, $4, $5 FROM updated
+		RETURNING id, application_id, from_stage, to_stage, actor_id, note, created_at`
+
+	history, err := scanApplicationHistory(a.write(ctx).QueryRowContext(ctx, query, to, id, from, actorID, note))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+	return history, nil
+}
+
+func (a *applicationRepository) GetApplicationHistory(ctx context.Context, applicationID int64) ([]*service_models.ApplicationHistory, error) {
+	query := `SELECT id, application_id, from_stage, to_stage, actor_id, note, created_at FROM application_history WHERE application_id = $1 ORDER BY created_at, id`
+	rows, err := a.read(ctx).QueryContext(ctx, query, applicationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []*service_models.ApplicationHistory
+	for rows.Next() {
+		history, err := scanApplicationHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, history)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (a *applicationRepository) queryApplications(ctx context.Context, query string, args ...any) ([]*service_models.Application, error) {
+	rows, err := a.read(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var applications []*service_models.Application
+	for rows.Next() {
+		application, err := scanApplication(rows)
+		if err != nil {
+			return nil, err
+		}
+		applications = append(applications, application)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return applications, nil
+}
+
+func (a *applicationRepository) write(ctx context.Context) Querier {
+	return writeQuerier(ctx, a.dbWrite, a.tx)
+}
+
+func (a *applicationRepository) read(ctx context.Context) Querier {
+	return readQuerier(ctx, a.dbWrite, a.dbRead, a.tx)
+}
+
+func (a *applicationRepository) GetWithTXT(tx *sql.Tx) Application {
+	return &applicationRepository{
+		dbWrite: a.dbWrite,
+		dbRead:  a.dbRead,
+		tx:      tx,
+	}
+}
+
+func NewApplicationRepository(dbWrite *sql.DB, dbRead *sql.DB) Application {
+	return &applicationRepository{
+		dbWrite: dbWrite,
+		dbRead:  dbRead,
+	}
+}
+
+func scanApplication(row rowScanner) (*service_models.Application, error) {
+	var application service_models.Application
+	var resume sql.NullString
+	if err := row.Scan(&application.ID, &application.JobID, &application.UserID, &application.CoverLetter, &resume, &application.Stage, &application.CreatedAt, &application.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if resume.Valid {
+		application.Resume = &resume.String
+	}
+	return &application, nil
+}
+
+func scanApplicationHistory(row rowScanner) (*service_models.ApplicationHistory, error) {
+	var history service_models.ApplicationHistory
+	var fromStage sql.NullString
+	var actorID sql.NullInt64
+	if err := row.Scan(&history.ID, &history.ApplicationID, &fromStage, &history.ToStage, &actorID, &history.Note, &history.CreatedAt); err != nil {
+		return nil, err
+	}
+	if fromStage.Valid {
+		stage := service_models.ApplicationStage(fromStage.String)
+		history.FromStage = &stage
+	}
+	if actorID.Valid {
+		history.ActorID = &actorID.Int64
+	}
+	return &history, nil
+}
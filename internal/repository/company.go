@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+)
+
+type Company interface {
+	CreateCompany(ctx context.Context, company *service_models.Company, ownerID int64) (*service_models.Company, error)
+	GetCompanyById(ctx context.Context, id int64) (*service_models.Company, error)
+	GetCompanyBySlug(ctx context.Context, slug string) (*service_models.Company, error)
+	GetAllCompanies(ctx context.Context, filter *service_models.CompanyFilter) ([]*service_models.Company, *service_models.Metadata, error)
+	UpdateCompany(ctx context.Context, company *service_models.Company) (*service_models.Company, error)
+	DeleteCompany(ctx context.Context, id int64) error
+	GetCompanyMembers(ctx context.Context, companyID int64) ([]*service_models.CompanyMember, error)
+	GetMemberRole(ctx context.Context, companyID, userID int64) (service_models.CompanyRole, error)
+	CountOwners(ctx context.Context, companyID int64) (int, error)
+	SetMember(ctx context.Context, companyID, userID int64, role service_models.CompanyRole) (*service_models.CompanyMember, error)
+	RemoveMember(ctx context.Context, companyID, userID int64) error
+	GetWithTXT(tx *sql.Tx) Company
+}
+
+const companyColumns = `id, name, slug, website, description, logo, created_at, updated_at`
+
+type companyRepository struct {
+	dbWrite *sql.DB
+	dbRead  *sql.DB
+	tx      *sql.Tx
+}
+
+func (c *companyRepository) CreateCompany(ctx context.Context, company *service_models.Company, ownerID int64) (*service_models.Company, error) {
+	query := `
+		WITH inserted AS (
+			INSERT INTO companies (name, slug, website, description, logo) VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, created_at, updated_at
+		), owner AS (
+			INSERT INTO company_members (company_id, user_id, role)
+			SELECT id, $6, 'owner' FROM inserted
+		)
+		SELECT id, created_at, updated_at FROM inserted`
+	err := c.write(ctx).QueryRowContext(ctx, query, company.Name, company.Slug, company.Website, company.Description, company.Logo, ownerID).Scan(&company.ID, &company.CreatedAt, &company.UpdatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "companies_slug_key"`:
+			return nil, ErrDuplicateCompanySlug
+		default:
+			return nil, err
+		}
+	}
+	return company, nil
+}
+
+func (c *companyRepository) GetCompanyById(ctx context.Context, id int64) (*service_models.Company, error) {
+	query := fmt.Sprintf(`SELECT %s FROM companies WHERE id = $1`, companyColumns)
+	return c.getCompany(ctx, query, id)
+}
+
+func (c *companyRepository) GetCompanyBySlug(ctx context.Context, slug string) (*service_models.Company, error) {
+	query := fmt.Sprintf(`SELECT %s FROM companies WHERE slug = $1`, companyColumns)
+	return c.getCompany(ctx, query, slug)
+}
+
+func (c *companyRepository) getCompany(ctx context.Context, query string, arg any) (*service_models.Company, error) {
+	company, err := scanCompany(c.read(ctx).QueryRowContext(ctx, query, arg))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return company, nil
+}
+
+func (c *companyRepository) GetAllCompanies(ctx context.Context, filter *service_models.CompanyFilter) ([]*service_models.Company, *service_models.Metadata, error) {
+	var totalRecords int
+	query := `SELECT count(*) FROM companies WHERE ($1 = '' OR name ILIKE '%' || $1 || '%')`
+	if err := c.read(ctx).QueryRowContext(ctx, query, filter.Name).Scan(&totalRecords); err != nil {
+		return nil, nil, err
+	}
+
+	query = fmt.Sprintf(`
+		SELECT %s FROM companies
+		WHERE ($1 = '' OR name ILIKE '%%' || $1 || '%%')
+		ORDER BY name, id
+		LIMIT $2 OFFSET $3`, companyColumns)
+	rows, err := c.read(ctx).QueryContext(ctx, query, filter.Name, filter.Limit(), filter.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var companies []*service_models.Company
+	for rows.Next() {
+		company, err := scanCompany(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		companies = append(companies, company)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	metadata := service_models.CalculateMetadata(totalRecords, filter.Page, filter.PageSize)
+	return companies, &metadata, nil
+}
+
+func (c *companyRepository) UpdateCompany(ctx context.Context, company *service_models.Company) (*service_models.Company, error) {
+	query := fmt.Sprintf(`
+		UPDATE companies SET name = $1, slug = $2, website = $3, description = $4, logo = $5, updated_at = NOW()
+		WHERE id = $6
+		RETURNING %s`, companyColumns)
+	updatedCompany, err := scanCompany(c.write(ctx).QueryRowContext(ctx, query, company.Name, company.Slug, company.Website, company.Description, company.Logo, company.ID))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		case err.Error() == `pq: duplicate key value violates unique constraint "companies_slug_key"`:
+			return nil, ErrDuplicateCompanySlug
+		default:
+			return nil, err
+		}
+	}
+
+	// Keep the display name on the company's jobs in step with the company.
+	query = `UPDATE jobs SET company = $1 WHERE company_id = $2 AND company <> $1`
+	if _, err = c.write(ctx).ExecContext(ctx, query, updatedCompany.Name, updatedCompany.ID); err != nil {
+		return nil, err
+	}
+	return updatedCompany, nil
+}
+
+func (c *companyRepository) DeleteCompany(ctx context.Context, id int64) error {
+	query := `DELETE FROM companies WHERE id = $1`
+	res, err := c.write(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (c *companyRepository) GetCompanyMembers(ctx context.Context, companyID int64) ([]*service_models.CompanyMember, error) {
+	query := `
+		SELECT m.company_id, m.user_id, u.username, m.role, m.created_at
+		FROM company_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.company_id = $1
+		ORDER BY m.role = 'owner' DESC, u.username`
+	rows, err := c.read(ctx).QueryContext(ctx, query, companyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var members []*service_models.CompanyMember
+	for rows.Next() {
+		var member service_models.CompanyMember
+		if err = rows.Scan(&member.CompanyID, &member.UserID, &member.Username, &member.Role, &member.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, &member)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (c *companyRepository) GetMemberRole(ctx context.Context, companyID, userID int64) (service_models.CompanyRole, error) {
+	var role service_models.CompanyRole
+	query := `SELECT role FROM company_members WHERE company_id = $1 AND user_id = $2`
+	if err := c.read(ctx).QueryRowContext(ctx, query, companyID, userID).Scan(&role); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return "", ErrRecordNotFound
+		default:
+			return "", err
+		}
+	}
+	return role, nil
+}
+
+func (c *companyRepository) CountOwners(ctx context.Context, companyID int64) (int, error) {
+	var owners int
+	query := `SELECT count(*) FROM company_members WHERE company_id = $1 AND role = 'owner'`
+	if err := c.read(ctx).QueryRowContext(ctx, query, companyID).Scan(&owners); err != nil {
+		return 0, err
+	}
+	return owners, nil
+}
+
+func (c *companyRepository) SetMember(ctx context.Context, companyID, userID int64, role service_models.CompanyRole) (*service_models.CompanyMember, error) {
+	query := `
+		WITH member AS (
+			INSERT INTO company_members (company_id, user_id, role) VALUES ($1, $2, $3)
+			ON CONFLICT (company_id, user_id) DO UPDATE SET role = EXCLUDED.role
+			RETURNING company_id, user_id, role, created_at
+		)
+		SELECT member.company_id, member.user_id, u.username, member.role, member.created_at
+		FROM member JOIN users u ON u.id = member.user_id`
+	var member service_models.CompanyMember
+	err := c.write(ctx).QueryRowContext(ctx, query, companyID, userID, role).Scan(&member.CompanyID, &member.UserID, &member.Username, &member.Role, &member.CreatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: insert or update on table "company_members" violates foreign key constraint "company_members_user_id_fkey"`,
+			err.Error() == `pq: insert or update on table "company_members" violates foreign key constraint "company_members_company_id_fkey"`:
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &member, nil
+}
+
+func (c *companyRepository) RemoveMember(ctx context.Context, companyID, userID int64) error {
+	query := `DELETE FROM company_members WHERE company_id = $1 AND user_id = $2`
+	res, err := c.write(ctx).ExecContext(ctx, query, companyID, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (c *companyRepository) write(ctx context.Context) Querier {
+	return writeQuerier(ctx, c.dbWrite, c.tx)
+}
+
+func (c *companyRepository) read(ctx context.Context) Querier {
+	return readQuerier(ctx, c.dbWrite, c.dbRead, c.tx)
+}
+
+func (c *companyRepository) GetWithTXT(tx *sql.Tx) Company {
+	return &companyRepository{
+		dbWrite: c.dbWrite,
+		dbRead:  c.dbRead,
+		tx:      tx,
+	}
+}
+
+func NewCompanyRepository(dbWrite *sql.DB, dbRead *sql.DB) Company {
+	return &companyRepository{
+		dbWrite: dbWrite,
+		dbRead:  dbRead,
+	}
+}
+
+func scanCompany(row rowScanner) (*service_models.Company, error) {
+	var company service_models.Company
+	if err := row.Scan(&company.ID, &company.Name, &company.Slug, &company.Website, &company.Description, &company.Logo, &company.CreatedAt, &company.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &company, nil
+}
@@ -3,8 +3,57 @@ package repository
 import "errors"
 
 var (
-	ErrRecordNotFound     = errors.New("record not found")
-	ErrDuplicateUsernames = errors.New("this username is already taken")
-	ErrDuplicateEmails    = errors.New("this email is already taken")
-	ErrUnAuthorized       = errors.New("unauthorized")
+	ErrRecordNotFound       = errors.New("record not found")
+	ErrDuplicateUsernames   = errors.New("this username is already taken")
+	ErrDuplicateEmails      = errors.New("this email is already taken")
+	ErrUnAuthorized         = errors.New("unauthorized")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+	ErrDuplicateApplication = errors.New("you have already applied to this job")
+	ErrOwnJobApplication    = errors.New("you cannot apply to a job you manage")
+	ErrInvalidStage         = errors.New("invalid stage transition")
+	ErrEditConflict         = errors.New("unable to update the record due to an edit conflict, please try again")
+	ErrInvalidJobStatus     = errors.New("invalid job status transition")
+	ErrInvalidExpiry        = errors.New("expires_at must be in the future")
+	ErrJobNotOpen           = errors.New("this job is not accepting applications")
+	ErrInvalidSalary        = errors.New("invalid salary range")
+	ErrDuplicateCompanySlug = errors.New("a company with this slug already exists")
+	ErrInvalidCompanySlug   = errors.New("company slug must contain at least one letter")
+	ErrLastCompanyOwner     = errors.New("a company must keep at least one owner")
+	ErrDuplicateTag         = errors.New("a tag with this name already exists")
+	ErrUnknownTag           = errors.New("unknown tag")
+	ErrInvalidRefreshToken  = errors.New("invalid or expired refresh token")
+	ErrTokenRevoked         = errors.New("token has been revoked")
+	ErrInvalidResetToken    = errors.New("invalid or expired password reset token")
+	ErrInvalidVerifyToken   = errors.New("invalid or expired email verification token")
+	ErrEmailAlreadyVerified = errors.New("this email address is already verified")
+	ErrEmailNotVerified     = errors.New("you must verify your email address first")
+	ErrVerificationLimited  = errors.New("a verification email was sent recently, please try again later")
+	ErrInvalidMFACode       = errors.New("invalid two-factor authentication code")
+	ErrInvalidMFAToken      = errors.New("invalid or expired two-factor authentication challenge")
+	ErrMFAAlreadyEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled        = errors.New("two-factor authentication is not enabled")
+	ErrMFANotStarted        = errors.New("two-factor enrollment has not been started")
+	ErrMFALocked            = errors.New("too many failed two-factor attempts, please try again later")
+	ErrMFARequired          = errors.New("two-factor authentication is required for admin accounts")
+	ErrDuplicateRole        = errors.New("a role with this name already exists")
+	ErrUnknownRole          = errors.New("unknown role")
+	ErrUnknownPermission    = errors.New("unknown permission")
+	ErrBuiltinRole          = errors.New("built-in roles cannot be renamed or deleted")
+	ErrAdminRoleLocked      = errors.New("the admin role always has every permission")
+	ErrLastAdmin            = errors.New("at least one user must keep the admin role")
+	ErrInvalidCredentials   = errors.New("invalid username or password")
+	ErrLoginLocked          = errors.New("too many failed login attempts, please try again later")
+	ErrInvalidUnlockToken   = errors.New("invalid or expired account unlock token")
+	ErrInvalidAPIKey        = errors.New("invalid, expired or revoked API key")
+	ErrInvalidScope         = errors.New("API keys can only be given permissions you have")
+	ErrTooManyAPIKeys       = errors.New("you have reached the maximum number of API keys")
+	ErrOIDCDisabled         = errors.New("single sign-on is not configured")
+	ErrInvalidOIDCState     = errors.New("invalid or expired sign-in session, please start again")
+	ErrOIDCLoginFailed      = errors.New("sign-in with the identity provider failed")
+	ErrOIDCEmailRequired    = errors.New("the identity provider did not share a verified email address")
+	ErrOIDCAccountNotLinked = errors.New("no account is linked to this identity")
+	ErrDuplicateIdentity    = errors.New("this identity is already linked to an account")
+	ErrIncorrectPassword    = errors.New("current password is incorrect")
+	ErrTxRequired           = errors.New("this operation must run in a transaction")
+	ErrSeedDataExists       = errors.New("seed data already exists, remove it with `seed --purge` first")
 )
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+)
+
+type Identity interface {
+	GetIdentity(ctx context.Context, issuer, subject string) (*service_models.UserIdentity, error)
+	CreateIdentity(ctx context.Context, identity *service_models.UserIdentity) error
+	CreateUserWithIdentity(ctx context.Context, user *service_models.User, identity *service_models.UserIdentity) error
+	RecordIdentityLogin(ctx context.Context, id int64, email string) error
+	GetWithTXT(tx *sql.Tx) Identity
+}
+
+type identityRepository struct {
+	dbWrite *sql.DB
+	dbRead  *sql.DB
+	tx      *sql.Tx
+}
+
+func (i *identityRepository) GetIdentity(ctx context.Context, issuer, subject string) (*service_models.UserIdentity, error) {
+	var identity service_models.UserIdentity
+	query := `SELECT id, user_id, issuer, subject, email, last_login_at, created_at FROM user_identities WHERE issuer = $1 AND subject = $2`
+	err := i.read(ctx).QueryRowContext(ctx, query, issuer, subject).Scan(&identity.ID, &identity.UserID, &identity.Issuer, &identity.Subject, &identity.Email, &identity.LastLoginAt, &identity.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &identity, nil
+}
+
+func (i *identityRepository) CreateIdentity(ctx context.Context, identity *service_models.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (user_id, issuer, subject, email)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	err := i.write(ctx).QueryRowContext(ctx, query, identity.UserID, identity.Issuer, identity.Subject, identity.Email).Scan(&identity.ID, &identity.CreatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "user_identities_issuer_subject_key"`:
+			return ErrDuplicateIdentity
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateUserWithIdentity creates a user without a password, its roles and its
+// identity in one statement, so a failure never leaves a user that cannot log
+// in. The email counts as verified when user.EmailVerifiedAt is set.
+func (i *identityRepository) CreateUserWithIdentity(ctx context.Context, user *service_models.User, identity *service_models.UserIdentity) error {
+	query := `
+		WITH created AS (
+			INSERT INTO users (username, password, email, email_verified_at)
+			VALUES ($1, NULL, $2, $3)
+			RETURNING id, created_at
+		), assigned AS (
+			INSERT INTO user_roles (user_id, role_id)
+			SELECT created.id, roles.id FROM created, roles WHERE roles.name = ANY($4)
+		), linked AS (
+			INSERT INTO user_identities (user_id, issuer, subject, email)
+			SELECT created.id, $5, $6, $2 FROM created
+			RETURNING id, created_at
+		)
+		SELECT created.id, created.created_at, linked.id, linked.created_at FROM created, linked`
+
+	err := i.write(ctx).QueryRowContext(ctx, query, user.Username, user.Email, user.EmailVerifiedAt, pq.Array(user.Roles), identity.Issuer, identity.Subject).
+		Scan(&user.ID, &user.CreateAt, &identity.ID, &identity.CreatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
+			return ErrDuplicateEmails
+		case err.Error() == `pq: duplicate key value violates unique constraint "users_username_key"`:
+			return ErrDuplicateUsernames
+		case err.Error() == `pq: duplicate key value violates unique constraint "user_identities_issuer_subject_key"`:
+			return ErrDuplicateIdentity
+		default:
+			return err
+		}
+	}
+	identity.UserID = user.ID
+	identity.Email = user.Email
+	return nil
+}
+
+// RecordIdentityLogin stamps the identity with the time of the sign-in and
+// the email address the provider reported.
+func (i *identityRepository) RecordIdentityLogin(ctx context.Context, id int64, email string) error {
+	query := `UPDATE user_identities SET last_login_at = NOW(), email = $2 WHERE id = $1`
+	_, err := i.write(ctx).ExecContext(ctx, query, id, email)
+	return err
+}
+
+func (i *identityRepository) write(ctx context.Context) Querier {
+	return writeQuerier(ctx, i.dbWrite, i.tx)
+}
+
+func (i *identityRepository) read(ctx context.Context) Querier {
+	return readQuerier(ctx, i.dbWrite, i.dbRead, i.tx)
+}
+
+func (i *identityRepository) GetWithTXT(tx *sql.Tx) Identity {
+	return &identityRepository{
+		dbWrite: i.dbWrite,
+		dbRead:  i.dbRead,
+		tx:      tx,
+	}
+}
+
+func NewIdentityRepository(dbWrite *sql.DB, dbRead *sql.DB) Identity {
+	return &identityRepository{
+		dbWrite: dbWrite,
+		dbRead:  dbRead,
+	}
+}
@@ -3,20 +3,52 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/lib/pq"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"html"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Job interface {
 	CreateJob(ctx context.Context, job *service_models.Job) (*service_models.Job, error)
-	GetAllJobs(ctx context.Context) ([]*service_models.Job, error)
-	GetAllJobsByUserID(ctx context.Context, userID int64) ([]*service_models.Job, error)
+	GetAllJobs(ctx context.Context, filter *service_models.JobFilter) ([]*service_models.Job, *service_models.Metadata, error)
+	SearchJobs(ctx context.Context, filter *service_models.JobSearchFilter) ([]*service_models.JobSearchResult, *service_models.Metadata, error)
 	GetJobById(ctx context.Context, id int64) (*service_models.Job, error)
 	UpdateJob(ctx context.Context, job *service_models.Job) (*service_models.Job, error)
+	UpdateJobStatus(ctx context.Context, id int64, from, to service_models.JobStatus) (*service_models.Job, error)
 	DeleteJob(ctx context.Context, id int64) error
+	SweepJobs(ctx context.Context, archiveBefore time.Time) (*service_models.JobSweepResult, error)
 	GetWithTXT(tx *sql.Tx) Job
 }
 
+// jobTagsColumn selects the sorted tag names of a job. Every statement that
+// uses jobColumns must have the jobs table in scope under its own name.
+const jobTagsColumn = `ARRAY(SELECT t.name FROM job_tags jt JOIN tags t ON t.id = jt.tag_id WHERE jt.job_id = jobs.id ORDER BY t.name)`
+
+const jobColumns = `id, title, description, location, company, company_id, salary, salary_min, salary_max, salary_currency, salary_period, status, expires_at, published_at, closed_at, archived_at, created_at, user_id, ` + jobTagsColumn
+
+// jobSweepLockKey is the PostgreSQL advisory lock that makes sure only one
+// replica sweeps the jobs table at a time.
+const jobSweepLockKey int64 = 4_207_001
+
+// jobSortColumns maps the sort keys accepted by JobFilter to the SQL
+// expressions used for ordering and keyset pagination.
+var jobSortColumns = map[string]string{
+	"created_at": "created_at",
+	"title":      "title",
+	"company":    "company",
+	"salary_min": "COALESCE(salary_min, 0)",
+	"salary_max": "COALESCE(salary_max, 0)",
+}
+
+const publishedJobCondition = `status = 'published' AND (expires_at IS NULL OR expires_at > NOW())`
+
 type jobRepository struct {
 	dbWrite *sql.DB
 	dbRead  *sql.DB
@@ -24,9 +56,18 @@ type jobRepository struct {
 }
 
 func (j *jobRepository) CreateJob(ctx context.Context, job *service_models.Job) (*service_models.Job, error) {
-	query := `INSERT INTO jobs (title, description, company, location, salary,user_id) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at;`
+	query := `
+		WITH inserted AS (
+			INSERT INTO jobs (title, description, company, company_id, location, salary, salary_min, salary_max, salary_currency, salary_period, user_id, status, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			RETURNING id, created_at
+		), tagged AS (
+			INSERT INTO job_tags (job_id, tag_id)
+			SELECT inserted.id, tags.id FROM inserted, tags WHERE tags.name = ANY($14)
+		)
+		SELECT id, created_at FROM inserted`
 	var id int64
-	err := j.dbWrite.QueryRowContext(ctx, query, job.Title, job.Description, job.Company, job.Location, job.Salary, job.UserID).Scan(&id, &job.CreatedAt)
+	err := j.write(ctx).QueryRowContext(ctx, query, job.Title, job.Description, job.Company, job.CompanyID, job.Location, job.Salary, job.SalaryMin, job.SalaryMax, job.SalaryCurrency, job.SalaryPeriod, job.UserID, job.Status, job.ExpiresAt, pq.Array(job.Tags)).Scan(&id, &job.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -34,53 +75,127 @@ func (j *jobRepository) CreateJob(ctx context.Context, job *service_models.Job)
 	return job, nil
 }
 
-func (j *jobRepository) GetAllJobs(ctx context.Context) ([]*service_models.Job, error) {
-	query := `SELECT id, title, description, location, company, salary, created_at, user_id FROM jobs`
-	rows, err := j.dbRead.QueryContext(ctx, query)
+func (j *jobRepository) GetAllJobs(ctx context.Context, filter *service_models.JobFilter) ([]*service_models.Job, *service_models.Metadata, error) {
+	where, args := jobFilterClause(filter)
+
+	var totalRecords int
+	query := fmt.Sprintf(`SELECT count(*) FROM jobs WHERE %s`, where)
+	if err := j.read(ctx).QueryRowContext(ctx, query, args...).Scan(&totalRecords); err != nil {
+		return nil, nil, err
+	}
+
+	sortColumn := jobSortColumns[filter.Sort]
+	comparison := ">"
+	if filter.Direction == "desc" {
+		comparison = "<"
+	}
+
+	if filter.Cursor != "" {
+		value, id, err := decodeJobCursor(filter.Cursor, filter.Sort, filter.Direction)
+		if err != nil {
+			return nil, nil, err
+		}
+		args = append(args, value, id)
+		where = fmt.Sprintf(`%s AND (%s, id) %s ($%d, $%d)`, where, sortColumn, comparison, len(args)-1, len(args))
+	}
+
+	args = append(args, filter.Limit()+1, filter.Offset())
+	query = fmt.Sprintf(`SELECT %s FROM jobs WHERE %s ORDER BY %s %s, id %s LIMIT $%d OFFSET $%d`,
+		jobColumns, where, sortColumn, filter.Direction, filter.Direction, len(args)-1, len(args))
+
+	rows, err := j.read(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 	var jobs []*service_models.Job
 	for rows.Next() {
-		var job service_models.Job
-		if err = rows.Scan(&job.ID, &job.Title, &job.Description, &job.Location, &job.Company, &job.Salary, &job.CreatedAt, &job.UserID); err != nil {
-			return nil, err
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, nil, err
 		}
-		jobs = append(jobs, &job)
+		jobs = append(jobs, job)
 	}
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	var metadata service_models.Metadata
+	if filter.Cursor != "" {
+		metadata = service_models.Metadata{PageSize: filter.PageSize, TotalRecords: totalRecords}
+	} else {
+		metadata = service_models.CalculateMetadata(totalRecords, filter.Page, filter.PageSize)
+	}
+
+	if len(jobs) > filter.Limit() {
+		jobs = jobs[:filter.Limit()]
+		metadata.NextCursor = encodeJobCursor(jobs[len(jobs)-1], filter.Sort, filter.Direction)
 	}
-	return jobs, nil
+
+	return jobs, &metadata, nil
+}
+
+const (
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+)
+
+var highlightTags = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// highlightHTML escapes a ts_headline result and turns its match delimiters
+// into <mark> tags, so job text can never inject markup of its own.
+func highlightHTML(headline string) string {
+	return highlightTags.Replace(html.EscapeString(headline))
 }
 
-func (j *jobRepository) GetAllJobsByUserID(ctx context.Context, userID int64) ([]*service_models.Job, error) {
-	query := `SELECT id, title, description, location, company, salary, created_at, user_id FROM jobs WHERE user_id = $1`
-	rows, err := j.dbRead.QueryContext(ctx, query, userID)
+func (j *jobRepository) SearchJobs(ctx context.Context, filter *service_models.JobSearchFilter) ([]*service_models.JobSearchResult, *service_models.Metadata, error) {
+	var totalRecords int
+	query := `SELECT count(*) FROM jobs WHERE search_vector @@ websearch_to_tsquery('english', $1) AND ` + publishedJobCondition
+	if err := j.read(ctx).QueryRowContext(ctx, query, filter.Query).Scan(&totalRecords); err != nil {
+		return nil, nil, err
+	}
+
+	query = fmt.Sprintf(`
+		SELECT %s,
+			ts_rank_cd(search_vector, q) AS rank,
+			ts_headline('english', translate(title, $4, ''), q, 'HighlightAll=true, ' || $5),
+			ts_headline('english', translate(description, $4, ''), q, 'MaxFragments=2, MaxWords=30, MinWords=10, ' || $5)
+		FROM jobs, websearch_to_tsquery('english', $1) AS q
+		WHERE search_vector @@ q AND %s
+		ORDER BY rank DESC, id DESC
+		LIMIT $2 OFFSET $3`, jobColumns, publishedJobCondition)
+
+	// Matches are delimited with private-use characters, which are stripped
+	// from the text first, and only become <mark> tags once the text around
+	// them has been escaped.
+	selectors := fmt.Sprintf(`StartSel="%s", StopSel="%s"`, highlightStart, highlightStop)
+	rows, err := j.read(ctx).QueryContext(ctx, query, filter.Query, filter.Limit(), filter.Offset(), highlightStart+highlightStop, selectors)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
-	var jobs []*service_models.Job
+	var results []*service_models.JobSearchResult
 	for rows.Next() {
-		var job service_models.Job
-		if err = rows.Scan(&job.ID, &job.Title, &job.Description, &job.Location, &job.Company, &job.Salary, &job.CreatedAt, &job.UserID); err != nil {
-			return nil, err
+		result := &service_models.JobSearchResult{Job: &service_models.Job{}}
+		dest := append(jobDest(result.Job), &result.Rank, &result.TitleHighlight, &result.Snippet)
+		if err = rows.Scan(dest...); err != nil {
+			return nil, nil, err
 		}
-		jobs = append(jobs, &job)
+		result.TitleHighlight, result.Snippet = highlightHTML(result.TitleHighlight), highlightHTML(result.Snippet)
+		results = append(results, result)
 	}
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return jobs, nil
+
+	metadata := service_models.CalculateMetadata(totalRecords, filter.Page, filter.PageSize)
+	return results, &metadata, nil
 }
 
 func (j *jobRepository) GetJobById(ctx context.Context, id int64) (*service_models.Job, error) {
-	query := `SELECT id, title, description, location, company, salary, created_at, user_id FROM jobs WHERE id = $1`
+	query := fmt.Sprintf(`SELECT %s FROM jobs WHERE id = $1`, jobColumns)
 
-	var job service_models.Job
-	err := j.dbRead.QueryRowContext(ctx, query, id).Scan(&job.ID, &job.Title, &job.Description, &job.Location, &job.Company, &job.Salary, &job.CreatedAt, &job.UserID)
+	job, err := scanJob(j.read(ctx).QueryRowContext(ctx, query, id))
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -89,12 +204,29 @@ func (j *jobRepository) GetJobById(ctx context.Context, id int64) (*service_mode
 			return nil, err
 		}
 	}
-	return &job, nil
+	return job, nil
 }
 
 func (j *jobRepository) UpdateJob(ctx context.Context, job *service_models.Job) (*service_models.Job, error) {
-	query := `UPDATE jobs SET title = $1, description = $2, company = $3, location = $4, salary = $5 WHERE id = $6`
-	_, err := j.dbWrite.ExecContext(ctx, query, &job.Title, &job.Description, &job.Company, &job.Location, &job.Salary, &job.ID)
+	// The tag changes are not visible to RETURNING within the same statement,
+	// so the returned job gets the requested tags afterwards.
+	query := fmt.Sprintf(`
+		WITH updated AS (
+			UPDATE jobs SET title = $1, description = $2, company = $3, company_id = $4, location = $5, salary = $6,
+				salary_min = $7, salary_max = $8, salary_currency = $9, salary_period = $10, expires_at = $11
+			WHERE id = $12
+			RETURNING %s
+		), wanted AS (
+			SELECT id FROM tags WHERE name = ANY($13)
+		), removed AS (
+			DELETE FROM job_tags WHERE job_id IN (SELECT id FROM updated) AND tag_id NOT IN (SELECT id FROM wanted)
+		), added AS (
+			INSERT INTO job_tags (job_id, tag_id)
+			SELECT updated.id, wanted.id FROM updated, wanted
+			ON CONFLICT (job_id, tag_id) DO NOTHING
+		)
+		SELECT * FROM updated`, jobColumns)
+	updatedJob, err := scanJob(j.write(ctx).QueryRowContext(ctx, query, job.Title, job.Description, job.Company, job.CompanyID, job.Location, job.Salary, job.SalaryMin, job.SalaryMax, job.SalaryCurrency, job.SalaryPeriod, job.ExpiresAt, job.ID, pq.Array(job.Tags)))
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -104,12 +236,33 @@ func (j *jobRepository) UpdateJob(ctx context.Context, job *service_models.Job)
 		}
 	}
 
+	updatedJob.Tags = job.Tags
+	return updatedJob, nil
+}
+
+func (j *jobRepository) UpdateJobStatus(ctx context.Context, id int64, from, to service_models.JobStatus) (*service_models.Job, error) {
+	query := fmt.Sprintf(`
+		UPDATE jobs SET
+			status = $1,
+			published_at = CASE WHEN $1 = 'published' THEN NOW() ELSE published_at END,
+			closed_at = CASE WHEN $1 = 'closed' THEN NOW() ELSE closed_at END
+		WHERE id = $2 AND status = $3
+		RETURNING %s`, jobColumns)
+	job, err := scanJob(j.write(ctx).QueryRowContext(ctx, query, to, id, from))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
 	return job, nil
 }
 
 func (j *jobRepository) DeleteJob(ctx context.Context, id int64) error {
 	query := `DELETE FROM jobs WHERE id = $1`
-	_, err := j.dbWrite.ExecContext(ctx, query, id)
+	_, err := j.write(ctx).ExecContext(ctx, query, id)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -121,6 +274,48 @@ func (j *jobRepository) DeleteJob(ctx context.Context, id int64) error {
 	return nil
 }
 
+func (j *jobRepository) SweepJobs(ctx context.Context, archiveBefore time.Time) (*service_models.JobSweepResult, error) {
+	// The advisory lock is held until the transaction ends.
+	if j.tx == nil {
+		return nil, ErrTxRequired
+	}
+
+	result := &service_models.JobSweepResult{}
+	if err := j.tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, jobSweepLockKey).Scan(&result.Acquired); err != nil {
+		return nil, err
+	}
+	if !result.Acquired {
+		return result, nil
+	}
+
+	query := `UPDATE jobs SET status = 'expired', closed_at = expires_at WHERE status = 'published' AND expires_at <= NOW()`
+	res, err := j.tx.ExecContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error expiring jobs: %w", err)
+	}
+	if result.Expired, err = res.RowsAffected(); err != nil {
+		return nil, err
+	}
+
+	query = `UPDATE jobs SET status = 'archived', archived_at = NOW() WHERE status IN ('closed', 'expired') AND closed_at < $1`
+	res, err = j.tx.ExecContext(ctx, query, archiveBefore)
+	if err != nil {
+		return nil, fmt.Errorf("error archiving jobs: %w", err)
+	}
+	if result.Archived, err = res.RowsAffected(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (j *jobRepository) write(ctx context.Context) Querier {
+	return writeQuerier(ctx, j.dbWrite, j.tx)
+}
+
+func (j *jobRepository) read(ctx context.Context) Querier {
+	return readQuerier(ctx, j.dbWrite, j.dbRead, j.tx)
+}
+
 func (j *jobRepository) GetWithTXT(tx *sql.Tx) Job {
 	return &jobRepository{
 		dbWrite: j.dbWrite,
@@ -135,3 +330,136 @@ func NewJobRepository(dbWrite *sql.DB, dbRead *sql.DB) Job {
 		dbRead:  dbRead,
 	}
 }
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func jobDest(job *service_models.Job) []any {
+	return []any{&job.ID, &job.Title, &job.Description, &job.Location, &job.Company, &job.CompanyID, &job.Salary, &job.SalaryMin, &job.SalaryMax, &job.SalaryCurrency, &job.SalaryPeriod, &job.Status, &job.ExpiresAt, &job.PublishedAt, &job.ClosedAt, &job.ArchivedAt, &job.CreatedAt, &job.UserID, pq.Array(&job.Tags)}
+}
+
+func scanJob(row rowScanner) (*service_models.Job, error) {
+	var job service_models.Job
+	if err := row.Scan(jobDest(&job)...); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func jobFilterClause(filter *service_models.JobFilter) (string, []any) {
+	conditions := []string{"TRUE"}
+	var args []any
+
+	if filter.Company != "" {
+		args = append(args, filter.Company)
+		conditions = append(conditions, fmt.Sprintf("company ILIKE '%%' || $%d || '%%'", len(args)))
+	}
+	if filter.Location != "" {
+		args = append(args, filter.Location)
+		conditions = append(conditions, fmt.Sprintf("location ILIKE '%%' || $%d || '%%'", len(args)))
+	}
+	if filter.UserID != 0 {
+		args = append(args, filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.CompanyID != 0 {
+		args = append(args, filter.CompanyID)
+		conditions = append(conditions, fmt.Sprintf("company_id = $%d", len(args)))
+	}
+	if len(filter.Tags) > 0 {
+		operator := "&&"
+		if filter.TagMode == "all" {
+			operator = "@>"
+		}
+		args = append(args, pq.Array(filter.Tags))
+		conditions = append(conditions, fmt.Sprintf("%s %s $%d::text[]", jobTagsColumn, operator, len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Currency != "" {
+		args = append(args, filter.Currency)
+		conditions = append(conditions, fmt.Sprintf("salary_currency = $%d", len(args)))
+	}
+	if filter.Period != "" {
+		args = append(args, filter.Period)
+		conditions = append(conditions, fmt.Sprintf("salary_period = $%d", len(args)))
+	}
+	if filter.SalaryMin != 0 {
+		args = append(args, filter.SalaryMin)
+		conditions = append(conditions, fmt.Sprintf("COALESCE(salary_max, salary_min) >= $%d", len(args)))
+	}
+	if filter.SalaryMax != 0 {
+		args = append(args, filter.SalaryMax)
+		conditions = append(conditions, fmt.Sprintf("COALESCE(salary_min, salary_max) <= $%d", len(args)))
+	}
+	if filter.PublishedOnly {
+		conditions = append(conditions, publishedJobCondition)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// jobCursor records the sort order it was issued for, because its value is
+// only comparable with the column it came from.
+type jobCursor struct {
+	Sort      string `json:"s"`
+	Direction string `json:"d"`
+	Value     string `json:"v"`
+	ID        int64  `json:"id"`
+}
+
+func encodeJobCursor(job *service_models.Job, sort, direction string) string {
+	cursor := jobCursor{Sort: sort, Direction: direction, ID: job.ID}
+	switch sort {
+	case "title":
+		cursor.Value = job.Title
+	case "company":
+		cursor.Value = job.Company
+	case "salary_min":
+		cursor.Value = strconv.FormatInt(valueOrZero(job.SalaryMin), 10)
+	case "salary_max":
+		cursor.Value = strconv.FormatInt(valueOrZero(job.SalaryMax), 10)
+	default:
+		cursor.Value = job.CreatedAt.Format(time.RFC3339Nano)
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func valueOrZero(v *int64) int64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// decodeJobCursor returns the value and ID of a cursor issued for the given
+// sort order. Cursors from another order, or whose value does not parse as the
+// sort column's type, are rejected.
+func decodeJobCursor(encoded, sort, direction string) (string, int64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+	var cursor jobCursor
+	if err = json.Unmarshal(data, &cursor); err != nil || cursor.ID < 1 {
+		return "", 0, ErrInvalidCursor
+	}
+	if cursor.Sort != sort || cursor.Direction != direction {
+		return "", 0, fmt.Errorf("%w: it belongs to a different sort or direction", ErrInvalidCursor)
+	}
+	switch sort {
+	case "title", "company":
+	case "salary_min", "salary_max":
+		_, err = strconv.ParseInt(cursor.Value, 10, 64)
+	default:
+		_, err = time.Parse(time.RFC3339Nano, cursor.Value)
+	}
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+	return cursor.Value, cursor.ID, nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"time"
+)
+
+type Login interface {
+	CountIPLoginFailures(ctx context.Context, ip string, window time.Duration) (int, error)
+	RecordLoginFailure(ctx context.Context, username, ip string, window time.Duration) (*service_models.LoginFailures, error)
+	ClearLoginFailures(ctx context.Context, userID int64, username string) error
+	GetLoginLock(ctx context.Context, userID int64) (*time.Time, error)
+	LockAccount(ctx context.Context, userID int64, username string, base, max time.Duration) (time.Time, error)
+	CreateAccountUnlock(ctx context.Context, userID int64, hash []byte, expiresAt time.Time) error
+	UnlockAccount(ctx context.Context, hash []byte) (int64, error)
+	GetWithTXT(tx *sql.Tx) Login
+}
+
+type loginRepository struct {
+	dbWrite *sql.DB
+	dbRead  *sql.DB
+	tx      *sql.Tx
+}
+
+// CountIPLoginFailures reads from the primary, since a replica lagging behind
+// would let an attacker go over the limit.
+func (l *loginRepository) CountIPLoginFailures(ctx context.Context, ip string, window time.Duration) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM login_failures WHERE ip_address = $1 AND created_at > NOW() - make_interval(secs => $2)`
+	if err := l.primary().QueryRowContext(ctx, query, ip, window.Seconds()).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// RecordLoginFailure stores a failed login and returns the failures of the
+// username and of the IP address within window, the new one included. Rows
+// older than window are pruned on the way.
+func (l *loginRepository) RecordLoginFailure(ctx context.Context, username, ip string, window time.Duration) (*service_models.LoginFailures, error) {
+	query := `
+		WITH pruned AS (
+			DELETE FROM login_failures WHERE created_at <= NOW() - make_interval(secs => $3)
+		), recorded AS (
+			INSERT INTO login_failures (username, ip_address) VALUES ($1, $2)
+		)
+		SELECT
+			1 + COUNT(*) FILTER (WHERE username = $1),
+			1 + COUNT(*) FILTER (WHERE ip_address = $2)
+		FROM login_failures
+		WHERE (username = $1 OR ip_address = $2) AND created_at > NOW() - make_interval(secs => $3)`
+	var failures service_models.LoginFailures
+	if err := l.write(ctx).QueryRowContext(ctx, query, username, ip, window.Seconds()).Scan(&failures.ByUsername, &failures.ByIP); err != nil {
+		return nil, err
+	}
+	return &failures, nil
+}
+
+// ClearLoginFailures forgets the failures of a user after a successful login
+// and resets the lockout backoff.
+func (l *loginRepository) ClearLoginFailures(ctx context.Context, userID int64, username string) error {
+	query := `
+		WITH cleared AS (
+			DELETE FROM login_failures WHERE username = $2
+		)
+		UPDATE users SET login_lockouts = 0, login_locked_until = NULL
+		WHERE id = $1 AND (login_lockouts <> 0 OR login_locked_until IS NOT NULL)`
+	_, err := l.write(ctx).ExecContext(ctx, query, userID, username)
+	return err
+}
+
+// GetLoginLock reads from the primary so a fresh lock is never missed.
+func (l *loginRepository) GetLoginLock(ctx context.Context, userID int64) (*time.Time, error) {
+	var lockedUntil *time.Time
+	query := `SELECT login_locked_until FROM users WHERE id = $1`
+	if err := l.primary().QueryRowContext(ctx, query, userID).Scan(&lockedUntil); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return lockedUntil, nil
+}
+
+// LockAccount locks a user for base doubled once per earlier consecutive
+// lockout, capped at max, and clears the failures that led to it so counting
+// starts over once the lock expires. It returns when the lock ends.
+func (l *loginRepository) LockAccount(ctx context.Context, userID int64, username string, base, max time.Duration) (time.Time, error) {
+	query := `
+		WITH cleared AS (
+			DELETE FROM login_failures WHERE username = $2
+		)
+		UPDATE users SET
+			login_locked_until = NOW() + make_interval(secs => LEAST($3 * power(2, LEAST(login_lockouts, 30)), $4)),
+			login_lockouts = login_lockouts + 1
+		WHERE id = $1
+		RETURNING login_locked_until`
+	var lockedUntil time.Time
+	if err := l.write(ctx).QueryRowContext(ctx, query, userID, username, base.Seconds(), max.Seconds()).Scan(&lockedUntil); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return time.Time{}, ErrRecordNotFound
+		default:
+			return time.Time{}, err
+		}
+	}
+	return lockedUntil, nil
+}
+
+// CreateAccountUnlock stores a new unlock token and invalidates the user's
+// earlier ones, so only the most recent email works.
+func (l *loginRepository) CreateAccountUnlock(ctx context.Context, userID int64, hash []byte, expiresAt time.Time) error {
+	query := `
+		WITH superseded AS (
+			UPDATE account_unlocks SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL
+		)
+		INSERT INTO account_unlocks (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	_, err := l.write(ctx).ExecContext(ctx, query, userID, hash, expiresAt)
+	return err
+}
+
+// UnlockAccount consumes an unused, unexpired unlock token and lifts the lock
+// of its user. It returns the ID of the unlocked user.
+func (l *loginRepository) UnlockAccount(ctx context.Context, hash []byte) (int64, error) {
+	query := `
+		WITH consumed AS (
+			UPDATE account_unlocks SET used_at = NOW()
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+			RETURNING user_id
+		)
+		UPDATE users SET login_locked_until = NULL, login_lockouts = 0
+		FROM consumed
+		WHERE users.id = consumed.user_id
+		RETURNING users.id`
+	var userID int64
+	if err := l.write(ctx).QueryRowContext(ctx, query, hash).Scan(&userID); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, ErrInvalidUnlockToken
+		default:
+			return 0, err
+		}
+	}
+	return userID, nil
+}
+
+func (l *loginRepository) write(ctx context.Context) Querier {
+	return writeQuerier(ctx, l.dbWrite, l.tx)
+}
+
+func (l *loginRepository) primary() Querier {
+	return primaryQuerier(l.dbWrite, l.tx)
+}
+
+func (l *loginRepository) read(ctx context.Context) Querier {
+	return readQuerier(ctx, l.dbWrite, l.dbRead, l.tx)
+}
+
+func (l *loginRepository) GetWithTXT(tx *sql.Tx) Login {
+	return &loginRepository{
+		dbWrite: l.dbWrite,
+		dbRead:  l.dbRead,
+		tx:      tx,
+	}
+}
+
+func NewLoginRepository(dbWrite *sql.DB, dbRead *sql.DB) Login {
+	return &loginRepository{
+		dbWrite: dbWrite,
+		dbRead:  dbRead,
+	}
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"time"
+)
+
+type MFA interface {
+	GetTOTP(ctx context.Context, userID int64) (*service_models.TOTP, error)
+	SetPendingTOTP(ctx context.Context, userID int64, secret []byte) error
+	EnableTOTP(ctx context.Context, userID, step int64, codeHashes [][]byte) error
+	DisableTOTP(ctx context.Context, userID int64) error
+	UseTOTPStep(ctx context.Context, userID, step int64) error
+	UseRecoveryCode(ctx context.Context, userID int64, hash []byte) error
+	RecordMFAFailure(ctx context.Context, userID int64, maxAttempts int, lockout time.Duration) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes [][]byte) error
+	CountRecoveryCodes(ctx context.Context, userID int64) (int, error)
+	GetMFAPolicy(ctx context.Context) (*service_models.MFAPolicy, error)
+	UpdateMFAPolicy(ctx context.Context, requireAdminMFA bool) (*service_models.MFAPolicy, error)
+	GetWithTXT(tx *sql.Tx) MFA
+}
+
+type mfaRepository struct {
+	dbWrite *sql.DB
+	dbRead  *sql.DB
+	tx      *sql.Tx
+}
+
+// GetTOTP reads from the primary so a just-used time step is always seen.
+func (m *mfaRepository) GetTOTP(ctx context.Context, userID int64) (*service_models.TOTP, error) {
+	var totp service_models.TOTP
+	query := `SELECT totp_secret, totp_enabled_at, totp_last_step, mfa_failed_attempts, mfa_locked_until FROM users WHERE id = $1`
+	err := m.primary().QueryRowContext(ctx, query, userID).Scan(&totp.Secret, &totp.EnabledAt, &totp.LastStep, &totp.FailedAttempts, &totp.LockedUntil)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &totp, nil
+}
+
+// SetPendingTOTP stores a secret that becomes active once EnableTOTP confirms
+// a first code. Starting over replaces a pending secret.
+func (m *mfaRepository) SetPendingTOTP(ctx context.Context, userID int64, secret []byte) error {
+	query := `UPDATE users SET totp_secret = $2, totp_last_step = NULL WHERE id = $1 AND totp_enabled_at IS NULL`
+	res, err := m.write(ctx).ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableTOTP activates the pending secret and stores a fresh set of recovery
+// codes in one statement.
+func (m *mfaRepository) EnableTOTP(ctx context.Context, userID, step int64, codeHashes [][]byte) error {
+	query := `
+		WITH enabled AS (
+			UPDATE users SET totp_enabled_at = NOW(), totp_last_step = $2, mfa_failed_attempts = 0
+			WHERE id = $1 AND totp_enabled_at IS NULL AND totp_secret IS NOT NULL
+			RETURNING id
+		), cleared AS (
+			DELETE FROM recovery_codes WHERE user_id IN (SELECT id FROM enabled)
+		)
+		INSERT INTO recovery_codes (user_id, code_hash)
+		SELECT enabled.id, h FROM enabled, unnest($3::bytea[]) AS h
+		RETURNING user_id`
+	rows, err := m.write(ctx).QueryContext(ctx, query, userID, step, pq.Array(codeHashes))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		return ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+func (m *mfaRepository) DisableTOTP(ctx context.Context, userID int64) error {
+	query := `
+		WITH cleared AS (
+			DELETE FROM recovery_codes WHERE user_id = $1
+		)
+		UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL, mfa_failed_attempts = 0, mfa_locked_until = NULL
+		WHERE id = $1`
+	_, err := m.write(ctx).ExecContext(ctx, query, userID)
+	return err
+}
+
+// UseTOTPStep records a successful code. It returns ErrInvalidMFACode when the
+// step is not newer than the last one used, so a code cannot be replayed.
+func (m *mfaRepository) UseTOTPStep(ctx context.Context, userID, step int64) error {
+	query := `
+		UPDATE users SET totp_last_step = $2, mfa_failed_attempts = 0, mfa_locked_until = NULL
+		WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)`
+	res, err := m.write(ctx).ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (m *mfaRepository) UseRecoveryCode(ctx context.Context, userID int64, hash []byte) error {
+	query := `
+		WITH used AS (
+			UPDATE recovery_codes SET used_at = NOW()
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+			RETURNING user_id
+		)
+		UPDATE users SET mfa_failed_attempts = 0, mfa_locked_until = NULL
+		FROM used WHERE users.id = used.user_id`
+	res, err := m.write(ctx).ExecContext(ctx, query, userID, hash)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// RecordMFAFailure counts a wrong code and locks the second factor for
+// lockout once maxAttempts consecutive codes were wrong.
+func (m *mfaRepository) RecordMFAFailure(ctx context.Context, userID int64, maxAttempts int, lockout time.Duration) error {
+	query := `
+		UPDATE users SET
+			mfa_failed_attempts = CASE WHEN mfa_failed_attempts + 1 >= $2 THEN 0 ELSE mfa_failed_attempts + 1 END,
+			mfa_locked_until = CASE WHEN mfa_failed_attempts + 1 >= $2 THEN NOW() + make_interval(secs => $3) ELSE mfa_locked_until END
+		WHERE id = $1`
+	_, err := m.write(ctx).ExecContext(ctx, query, userID, maxAttempts, lockout.Seconds())
+	return err
+}
+
+func (m *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes [][]byte) error {
+	query := `
+		WITH cleared AS (
+			DELETE FROM recovery_codes WHERE user_id = $1
+		)
+		INSERT INTO recovery_codes (user_id, code_hash)
+		SELECT $1, h FROM unnest($2::bytea[]) AS h`
+	_, err := m.write(ctx).ExecContext(ctx, query, userID, pq.Array(codeHashes))
+	return err
+}
+
+func (m *mfaRepository) CountRecoveryCodes(ctx context.Context, userID int64) (int, error) {
+	var count int
+	query := `SELECT count(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+	if err := m.read(ctx).QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (m *mfaRepository) GetMFAPolicy(ctx context.Context) (*service_models.MFAPolicy, error) {
+	var policy service_models.MFAPolicy
+	query := `SELECT require_admin_mfa, updated_at FROM security_settings`
+	if err := m.read(ctx).QueryRowContext(ctx, query).Scan(&policy.RequireAdminMFA, &policy.UpdatedAt); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return &service_models.MFAPolicy{}, nil
+		default:
+			return nil, err
+		}
+	}
+	return &policy, nil
+}
+
+func (m *mfaRepository) UpdateMFAPolicy(ctx context.Context, requireAdminMFA bool) (*service_models.MFAPolicy, error) {
+	policy := service_models.MFAPolicy{RequireAdminMFA: requireAdminMFA}
+	query := `
+		INSERT INTO security_settings (id, require_admin_mfa) VALUES (true, $1)
+		ON CONFLICT (id) DO UPDATE SET require_admin_mfa = EXCLUDED.require_admin_mfa, updated_at = NOW()
+		RETURNING updated_at`
+	if err := m.write(ctx).QueryRowContext(ctx, query, requireAdminMFA).Scan(&policy.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (m *mfaRepository) write(ctx context.Context) Querier {
+	return writeQuerier(ctx, m.dbWrite, m.tx)
+}
+
+func (m *mfaRepository) primary() Querier {
+	return primaryQuerier(m.dbWrite, m.tx)
+}
+
+func (m *mfaRepository) read(ctx context.Context) Querier {
+	return readQuerier(ctx, m.dbWrite, m.dbRead, m.tx)
+}
+
+func (m *mfaRepository) GetWithTXT(tx *sql.Tx) MFA {
+	return &mfaRepository{
+		dbWrite: m.dbWrite,
+		dbRead:  m.dbRead,
+		tx:      tx,
+	}
+}
+
+func NewMFARepository(dbWrite *sql.DB, dbRead *sql.DB) MFA {
+	return &mfaRepository{
+		dbWrite: dbWrite,
+		dbRead:  dbRead,
+	}
+}
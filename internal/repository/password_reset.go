@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type PasswordReset interface {
+	CreatePasswordReset(ctx context.Context, userID int64, hash []byte, expiresAt time.Time) error
+	ResetPassword(ctx context.Context, hash []byte, passwordHash string) (int64, error)
+	GetWithTXT(tx *sql.Tx) PasswordReset
+}
+
+type passwordResetRepository struct {
+	dbWrite *sql.DB
+	dbRead  *sql.DB
+	tx      *sql.Tx
+}
+
+// CreatePasswordReset stores a new reset token and invalidates the user's
+// earlier ones, so only the most recent email works.
+func (p *passwordResetRepository) CreatePasswordReset(ctx context.Context, userID int64, hash []byte, expiresAt time.Time) error {
+	query := `
+		WITH superseded AS (
+			UPDATE password_resets SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL
+		)
+		INSERT INTO password_resets (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	_, err := p.write(ctx).ExecContext(ctx, query, userID, hash, expiresAt)
+	return err
+}
+
+// ResetPassword consumes an unused, unexpired reset token, sets the new
+// password, lifts any login lockout and ends every session of the user in one
+// statement. It returns the ID of the user whose password was changed.
+func (p *passwordResetRepository) ResetPassword(ctx context.Context, hash []byte, passwordHash string) (int64, error) {
+	query := `
+		WITH consumed AS (
+			UPDATE password_resets SET used_at = NOW()
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+			RETURNING user_id
+		), revoked AS (
+			UPDATE refresh_tokens SET revoked_at = NOW()
+			WHERE user_id IN (SELECT user_id FROM consumed) AND revoked_at IS NULL
+		)
+		UPDATE users SET password = $2, tokens_valid_after = NOW(), login_locked_until = NULL, login_lockouts = 0
+		FROM consumed
+		WHERE users.id = consumed.user_id
+		RETURNING users.id`
+	var userID int64
+	if err := p.write(ctx).QueryRowContext(ctx, query, hash, passwordHash).Scan(&userID); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, ErrInvalidResetToken
+		default:
+			return 0, err
+		}
+	}
+	return userID, nil
+}
+
+func (p *passwordResetRepository) write(ctx context.Context) Querier {
+	return writeQuerier(ctx, p.dbWrite, p.tx)
+}
+
+func (p *passwordResetRepository) read(ctx context.Context) Querier {
+	return readQuerier(ctx, p.dbWrite, p.dbRead, p.tx)
+}
+
+func (p *passwordResetRepository) GetWithTXT(tx *sql.Tx) PasswordReset {
+	return &passwordResetRepository{
+		dbWrite: p.dbWrite,
+		dbRead:  p.dbRead,
+		tx:      tx,
+	}
+}
+
+func NewPasswordResetRepository(dbWrite *sql.DB, dbRead *sql.DB) PasswordReset {
+	return &passwordResetRepository{
+		dbWrite: dbWrite,
+		dbRead:  dbRead,
+	}
+}
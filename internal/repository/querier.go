@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier is what *sql.DB and *sql.Tx have in common, so repositories can run
+// the same queries inside and outside a transaction.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// querier returns tx when a repository is bound to a transaction and db
+// otherwise. Reads also go through the transaction, so that they see its own
+// writes and the locks it holds.
+func querier(db *sql.DB, tx *sql.Tx) Querier {
+	if tx != nil {
+		return tx
+	}
+	return db
+}
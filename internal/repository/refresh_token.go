@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"time"
+)
+
+type RefreshToken interface {
+	CreateRefreshToken(ctx context.Context, token *service_models.RefreshToken, hash []byte) error
+	GetRefreshTokenByHash(ctx context.Context, hash []byte) (*service_models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, id int64, hash []byte, expiresAt time.Time) (*service_models.RefreshToken, error)
+	RevokeFamily(ctx context.Context, family string) error
+	RevokeUserTokens(ctx context.Context, userID int64) error
+	GetWithTXT(tx *sql.Tx) RefreshToken
+}
+
+type refreshTokenRepository struct {
+	dbWrite *sql.DB
+	dbRead  *sql.DB
+	tx      *sql.Tx
+}
+
+func (r *refreshTokenRepository) CreateRefreshToken(ctx context.Context, token *service_models.RefreshToken, hash []byte) error {
+	query := `INSERT INTO refresh_tokens (user_id, family, mfa, token_hash, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`
+	return r.write(ctx).QueryRowContext(ctx, query, token.UserID, token.Family, token.MFA, hash, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+}
+
+// GetRefreshTokenByHash reads from the primary so a token that was just
+// rotated is never mistaken for an unused one.
+func (r *refreshTokenRepository) GetRefreshTokenByHash(ctx context.Context, hash []byte) (*service_models.RefreshToken, error) {
+	var token service_models.RefreshToken
+	query := `SELECT id, user_id, family, mfa, expires_at, used_at, revoked_at, created_at FROM refresh_tokens WHERE token_hash = $1`
+	err := r.primary().QueryRowContext(ctx, query, hash).Scan(&token.ID, &token.UserID, &token.Family, &token.MFA, &token.ExpiresAt, &token.UsedAt, &token.RevokedAt, &token.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken marks the token as used and stores its successor in the
+// same family. It returns ErrEditConflict when the token was used or revoked
+// in the meantime.
+func (r *refreshTokenRepository) RotateRefreshToken(ctx context.Context, id int64, hash []byte, expiresAt time.Time) (*service_models.RefreshToken, error) {
+	query := `
+		WITH used AS (
+			UPDATE refresh_tokens SET used_at = NOW()
+			WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL
+			RETURNING user_id, family, mfa
+		)
+		INSERT INTO refresh_tokens (user_id, family, mfa, token_hash, expires_at)
+		SELECT user_id, family, mfa, $2, $3 FROM used
+		RETURNING id, user_id, family, mfa, expires_at, created_at`
+	var token service_models.RefreshToken
+	err := r.write(ctx).QueryRowContext(ctx, query, id, hash, expiresAt).Scan(&token.ID, &token.UserID, &token.Family, &token.MFA, &token.ExpiresAt, &token.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrEditConflict
+		default:
+			return nil, err
+		}
+	}
+	return &token, nil
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, family string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = NOW() WHERE family = $1 AND revoked_at IS NULL`
+	_, err := r.write(ctx).ExecContext(ctx, query, family)
+	return err
+}
+
+// RevokeUserTokens revokes every refresh token of the user and makes the
+// middleware reject access tokens issued before now.
+func (r *refreshTokenRepository) RevokeUserTokens(ctx context.Context, userID int64) error {
+	query := `
+		WITH revoked AS (
+			UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL
+		)
+		UPDATE users SET tokens_valid_after = NOW() WHERE id = $1`
+	_, err := r.write(ctx).ExecContext(ctx, query, userID)
+	return err
+}
+
+func (r *refreshTokenRepository) write(ctx context.Context) Querier {
+	return writeQuerier(ctx, r.dbWrite, r.tx)
+}
+
+func (r *refreshTokenRepository) primary() Querier {
+	return primaryQuerier(r.dbWrite, r.tx)
+}
+
+func (r *refreshTokenRepository) read(ctx context.Context) Querier {
+	return readQuerier(ctx, r.dbWrite, r.dbRead, r.tx)
+}
+
+func (r *refreshTokenRepository) GetWithTXT(tx *sql.Tx) RefreshToken {
+	return &refreshTokenRepository{
+		dbWrite: r.dbWrite,
+		dbRead:  r.dbRead,
+		tx:      tx,
+	}
+}
+
+func NewRefreshTokenRepository(dbWrite *sql.DB, dbRead *sql.DB) RefreshToken {
+	return &refreshTokenRepository{
+		dbWrite: dbWrite,
+		dbRead:  dbRead,
+	}
+}
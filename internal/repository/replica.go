@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/lib/pq"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type contextKey string
+
+// primaryPinKey holds the *atomic.Bool set once a request has written.
+const primaryPinKey contextKey = "primaryPin"
+
+// WithPrimaryPin returns a context in which reads go to the primary once
+// anything has been written through it, so a request sees its own writes
+// even while the replica lags behind.
+func WithPrimaryPin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryPinKey, &atomic.Bool{})
+}
+
+func pinToPrimary(ctx context.Context) {
+	if pin, ok := ctx.Value(primaryPinKey).(*atomic.Bool); ok {
+		pin.Store(true)
+	}
+}
+
+func pinnedToPrimary(ctx context.Context) bool {
+	pin, ok := ctx.Value(primaryPinKey).(*atomic.Bool)
+	return ok && pin.Load()
+}
+
+// ReplicaStatus is the last known state of a read replica.
+type ReplicaStatus struct {
+	Healthy   bool      `json:"healthy"`
+	Lag       float64   `json:"lag_seconds"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// ReplicaMonitor decides whether reads may go to a replica. The replica is
+// used while it answers and lags at most maxLag behind the primary.
+type ReplicaMonitor struct {
+	replica *sql.DB
+	maxLag  time.Duration
+	healthy atomic.Bool
+
+	mu     sync.Mutex
+	status ReplicaStatus
+}
+
+// replicas maps a replica pool to its monitor, so that repositories only need
+// the *sql.DB they were built with.
+var replicas sync.Map
+
+// NewReplicaMonitor registers replica for health-aware routing. It is treated
+// as unavailable until the first Check succeeds.
+func NewReplicaMonitor(replica *sql.DB, maxLag time.Duration) *ReplicaMonitor {
+	m := &ReplicaMonitor{replica: replica, maxLag: maxLag}
+	replicas.Store(replica, m)
+	return m
+}
+
+// Check measures the replication lag and updates the routing decision. A
+// replica that has replayed everything it received has no lag, however long
+// ago the primary last committed; otherwise the lag is the time since the
+// last replayed transaction. A database that is not in recovery is a primary
+// and has no lag.
+func (m *ReplicaMonitor) Check(ctx context.Context) ReplicaStatus {
+	status := ReplicaStatus{CheckedAt: time.Now()}
+	query := `
+		SELECT CASE WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM NOW() - pg_last_xact_replay_timestamp()), 0) END`
+	err := m.replica.QueryRowContext(ctx, query).Scan(&status.Lag)
+	switch {
+	case err != nil:
+		status.Error = err.Error()
+	case time.Duration(status.Lag*float64(time.Second)) > m.maxLag:
+		status.Error = "replication lag exceeds the maximum"
+	default:
+		status.Healthy = true
+	}
+
+	m.healthy.Store(status.Healthy)
+	m.mu.Lock()
+	m.status = status
+	m.mu.Unlock()
+	return status
+}
+
+// Status returns the result of the last Check.
+func (m *ReplicaMonitor) Status() ReplicaStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+// markUnavailable stops routing reads to the replica until the next Check.
+func (m *ReplicaMonitor) markUnavailable(err error) {
+	m.healthy.Store(false)
+	m.mu.Lock()
+	m.status.Healthy = false
+	m.status.Error = err.Error()
+	m.mu.Unlock()
+}
+
+// writeQuerier returns the querier for writes and pins the request to the
+// primary.
+func writeQuerier(ctx context.Context, dbWrite *sql.DB, tx *sql.Tx) Querier {
+	pinToPrimary(ctx)
+	return querier(dbWrite, tx)
+}
+
+// primaryQuerier returns the querier for reads that must not be stale, such as
+// the checks that enforce revocations. Unlike writeQuerier it leaves the rest
+// of the request free to read from the replica.
+func primaryQuerier(dbWrite *sql.DB, tx *sql.Tx) Querier {
+	return querier(dbWrite, tx)
+}
+
+// readQuerier returns the querier for reads: the transaction when one is
+// bound, the primary when the request has written or the replica is down, and
+// the replica otherwise.
+func readQuerier(ctx context.Context, dbWrite, dbRead *sql.DB, tx *sql.Tx) Querier {
+	if tx != nil {
+		return tx
+	}
+	if dbRead == dbWrite || pinnedToPrimary(ctx) {
+		return dbWrite
+	}
+
+	value, ok := replicas.Load(dbRead)
+	if !ok {
+		return dbRead
+	}
+	monitor := value.(*ReplicaMonitor)
+	if !monitor.healthy.Load() {
+		return dbWrite
+	}
+	return &replicaQuerier{replica: dbRead, primary: dbWrite, monitor: monitor}
+}
+
+// replicaQuerier runs reads on the replica and retries them on the primary
+// when the replica cannot be reached.
+type replicaQuerier struct {
+	replica *sql.DB
+	primary *sql.DB
+	monitor *ReplicaMonitor
+}
+
+func (q *replicaQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	result, err := q.replica.ExecContext(ctx, query, args...)
+	if q.failover(ctx, err) {
+		return q.primary.ExecContext(ctx, query, args...)
+	}
+	return result, err
+}
+
+func (q *replicaQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	rows, err := q.replica.QueryContext(ctx, query, args...)
+	if q.failover(ctx, err) {
+		return q.primary.QueryContext(ctx, query, args...)
+	}
+	return rows, err
+}
+
+func (q *replicaQuerier) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	row := q.replica.QueryRowContext(ctx, query, args...)
+	if q.failover(ctx, row.Err()) {
+		return q.primary.QueryRowContext(ctx, query, args...)
+	}
+	return row
+}
+
+// failover reports whether err means the replica is unreachable, in which case
+// it is taken out of rotation.
+func (q *replicaQuerier) failover(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || !isConnectionError(err) {
+		return false
+	}
+	q.monitor.markUnavailable(err)
+	return true
+}
+
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// Class 08 is connection exceptions; 57P01-57P03 are shutdowns and
+		// servers not accepting connections yet.
+		return pqErr.Code.Class() == "08" || pqErr.Code == "57P01" || pqErr.Code == "57P02" || pqErr.Code == "57P03"
+	}
+	return false
+}
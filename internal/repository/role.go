@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+)
+
+type Role interface {
+	CreateRole(ctx context.Context, role *service_models.Role) (*service_models.Role, error)
+	GetRoleById(ctx context.Context, id int64) (*service_models.Role, error)
+	GetAllRoles(ctx context.Context) ([]*service_models.Role, error)
+	UpdateRole(ctx context.Context, role *service_models.Role) (*service_models.Role, error)
+	DeleteRole(ctx context.Context, id int64) error
+	GetAllPermissions(ctx context.Context) ([]*service_models.Permission, error)
+	GetMissingPermissions(ctx context.Context, names []string) ([]string, error)
+	GetMissingRoles(ctx context.Context, names []string) ([]string, error)
+	GetUserRoles(ctx context.Context, userID int64) ([]string, error)
+	SetUserRoles(ctx context.Context, userID int64, names []string) error
+	CountRoleMembers(ctx context.Context, name string) (int, error)
+	GetWithTXT(tx *sql.Tx) Role
+}
+
+// rolePermissionsColumn selects the names of the role's permissions as a text
+// array.
+const rolePermissionsColumn = `ARRAY(SELECT p.name FROM role_permissions rp JOIN permissions p ON p.id = rp.permission_id WHERE rp.role_id = roles.id ORDER BY p.name)`
+
+var roleColumns = fmt.Sprintf(`id, name, description, %s, builtin, created_at, updated_at`, rolePermissionsColumn)
+
+type roleRepository struct {
+	dbWrite *sql.DB
+	dbRead  *sql.DB
+	tx      *sql.Tx
+}
+
+func (r *roleRepository) CreateRole(ctx context.Context, role *service_models.Role) (*service_models.Role, error) {
+	query := `
+		WITH created AS (
+			INSERT INTO roles (name, description) VALUES ($1, $2) RETURNING id, created_at, updated_at
+		), granted AS (
+			INSERT INTO role_permissions (role_id, permission_id)
+			SELECT created.id, permissions.id FROM created, permissions WHERE permissions.name = ANY($3)
+		)
+		SELECT id, created_at, updated_at FROM created`
+	err := r.write(ctx).QueryRowContext(ctx, query, role.Name, role.Description, pq.Array(role.Permissions)).Scan(&role.ID, &role.CreatedAt, &role.UpdatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "roles_name_key"`:
+			return nil, ErrDuplicateRole
+		default:
+			return nil, err
+		}
+	}
+	return role, nil
+}
+
+func (r *roleRepository) GetRoleById(ctx context.Context, id int64) (*service_models.Role, error) {
+	query := fmt.Sprintf(`SELECT %s FROM roles WHERE id = $1`, roleColumns)
+	role, err := scanRole(r.read(ctx).QueryRowContext(ctx, query, id))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return role, nil
+}
+
+func (r *roleRepository) GetAllRoles(ctx context.Context) ([]*service_models.Role, error) {
+	query := fmt.Sprintf(`SELECT %s FROM roles ORDER BY id`, roleColumns)
+	rows, err := r.read(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var roles []*service_models.Role
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// UpdateRole replaces the name, description and permissions of the role.
+// RETURNING cannot see the permission changes made by the same statement, so
+// the requested permissions are set on the result.
+func (r *roleRepository) UpdateRole(ctx context.Context, role *service_models.Role) (*service_models.Role, error) {
+	query := `
+		WITH wanted AS (
+			SELECT id FROM permissions WHERE name = ANY($4)
+		), removed AS (
+			DELETE FROM role_permissions WHERE role_id = $1 AND permission_id NOT IN (SELECT id FROM wanted)
+		), added AS (
+			INSERT INTO role_permissions (role_id, permission_id)
+			SELECT $1, id FROM wanted
+			ON CONFLICT DO NOTHING
+		)
+		UPDATE roles SET name = $2, description = $3, updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, name, description, builtin, created_at, updated_at`
+	var updated service_models.Role
+	err := r.write(ctx).QueryRowContext(ctx, query, role.ID, role.Name, role.Description, pq.Array(role.Permissions)).Scan(&updated.ID, &updated.Name, &updated.Description, &updated.Builtin, &updated.CreatedAt, &updated.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		case err.Error() == `pq: duplicate key value violates unique constraint "roles_name_key"`:
+			return nil, ErrDuplicateRole
+		default:
+			return nil, err
+		}
+	}
+	updated.Permissions = role.Permissions
+	return &updated, nil
+}
+
+func (r *roleRepository) DeleteRole(ctx context.Context, id int64) error {
+	query := `DELETE FROM roles WHERE id = $1 AND NOT builtin`
+	res, err := r.write(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *roleRepository) GetAllPermissions(ctx context.Context) ([]*service_models.Permission, error) {
+	query := `SELECT id, name, description FROM permissions ORDER BY name`
+	rows, err := r.read(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var permissions []*service_models.Permission
+	for rows.Next() {
+		var permission service_models.Permission
+		if err = rows.Scan(&permission.ID, &permission.Name, &permission.Description); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, &permission)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// GetMissingPermissions returns the names that do not match a permission.
+func (r *roleRepository) GetMissingPermissions(ctx context.Context, names []string) ([]string, error) {
+	var missing []string
+	query := `SELECT ARRAY(SELECT n FROM unnest($1::text[]) AS n WHERE NOT EXISTS (SELECT 1 FROM permissions WHERE name = n) ORDER BY n)`
+	if err := r.read(ctx).QueryRowContext(ctx, query, pq.Array(names)).Scan(pq.Array(&missing)); err != nil {
+		return nil, err
+	}
+	return missing, nil
+}
+
+// GetMissingRoles returns the names that do not match a role.
+func (r *roleRepository) GetMissingRoles(ctx context.Context, names []string) ([]string, error) {
+	var missing []string
+	query := `SELECT ARRAY(SELECT n FROM unnest($1::text[]) AS n WHERE NOT EXISTS (SELECT 1 FROM roles WHERE name = n) ORDER BY n)`
+	if err := r.read(ctx).QueryRowContext(ctx, query, pq.Array(names)).Scan(pq.Array(&missing)); err != nil {
+		return nil, err
+	}
+	return missing, nil
+}
+
+// GetUserRoles reads from the primary, as it is used to guard role changes.
+func (r *roleRepository) GetUserRoles(ctx context.Context, userID int64) ([]string, error) {
+	var roles []string
+	query := fmt.Sprintf(`SELECT %s FROM users WHERE id = $1`, userRolesColumn)
+	if err := r.primary().QueryRowContext(ctx, query, userID).Scan(pq.Array(&roles)); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+// SetUserRoles replaces the roles of the user with the named ones.
+func (r *roleRepository) SetUserRoles(ctx context.Context, userID int64, names []string) error {
+	query := `
+		WITH wanted AS (
+			SELECT id FROM roles WHERE name = ANY($2)
+		), removed AS (
+			DELETE FROM user_roles WHERE user_id = $1 AND role_id NOT IN (SELECT id FROM wanted)
+		)
+		INSERT INTO user_roles (user_id, role_id)
+		SELECT $1, id FROM wanted
+		ON CONFLICT DO NOTHING`
+	_, err := r.write(ctx).ExecContext(ctx, query, userID, pq.Array(names))
+	return err
+}
+
+// CountRoleMembers reads from the primary, like GetUserRoles.
+func (r *roleRepository) CountRoleMembers(ctx context.Context, name string) (int, error) {
+	var count int
+	query := `SELECT count(*) FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE r.name = $1`
+	if err := r.primary().QueryRowContext(ctx, query, name).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *roleRepository) write(ctx context.Context) Querier {
+	return writeQuerier(ctx, r.dbWrite, r.tx)
+}
+
+func (r *roleRepository) primary() Querier {
+	return primaryQuerier(r.dbWrite, r.tx)
+}
+
+func (r *roleRepository) read(ctx context.Context) Querier {
+	return readQuerier(ctx, r.dbWrite, r.dbRead, r.tx)
+}
+
+func (r *roleRepository) GetWithTXT(tx *sql.Tx) Role {
+	return &roleRepository{
+		dbWrite: r.dbWrite,
+		dbRead:  r.dbRead,
+		tx:      tx,
+	}
+}
+
+func NewRoleRepository(dbWrite *sql.DB, dbRead *sql.DB) Role {
+	return &roleRepository{
+		dbWrite: dbWrite,
+		dbRead:  dbRead,
+	}
+}
+
+func scanRole(row rowScanner) (*service_models.Role, error) {
+	var role service_models.Role
+	if err := row.Scan(&role.ID, &role.Name, &role.Description, pq.Array(&role.Permissions), &role.Builtin, &role.CreatedAt, &role.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
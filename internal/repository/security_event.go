@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+)
+
+type SecurityEvent interface {
+	CreateSecurityEvent(ctx context.Context, event *service_models.SecurityEvent) error
+	GetWithTXT(tx *sql.Tx) SecurityEvent
+}
+
+type securityEventRepository struct {
+	dbWrite *sql.DB
+	dbRead  *sql.DB
+	tx      *sql.Tx
+}
+
+func (s *securityEventRepository) CreateSecurityEvent(ctx context.Context, event *service_models.SecurityEvent) error {
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return err
+	}
+	if event.Details == nil {
+		details = []byte("{}")
+	}
+
+	query := `
+		INSERT INTO security_events (user_id, event, username, ip_address, details)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+	return s.write(ctx).QueryRowContext(ctx, query, event.UserID, event.Event, event.Username, event.IPAddress, details).Scan(&event.ID, &event.CreatedAt)
+}
+
+func (s *securityEventRepository) write(ctx context.Context) Querier {
+	return writeQuerier(ctx, s.dbWrite, s.tx)
+}
+
+func (s *securityEventRepository) read(ctx context.Context) Querier {
+	return readQuerier(ctx, s.dbWrite, s.dbRead, s.tx)
+}
+
+func (s *securityEventRepository) GetWithTXT(tx *sql.Tx) SecurityEvent {
+	return &securityEventRepository{
+		dbWrite: s.dbWrite,
+		dbRead:  s.dbRead,
+		tx:      tx,
+	}
+}
+
+func NewSecurityEventRepository(dbWrite *sql.DB, dbRead *sql.DB) SecurityEvent {
+	return &securityEventRepository{
+		dbWrite: dbWrite,
+		dbRead:  dbRead,
+	}
+}
@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+)
+
+// Seed bulk-loads generated development data with COPY and removes it again.
+// Generated rows have their seeded column set, and only those are purged.
+// Every method except HasSeedData must run in a transaction, because COPY
+// only works inside one.
+type Seed interface {
+	HasSeedData(ctx context.Context) (bool, error)
+	InsertUsers(ctx context.Context, users []*service_models.User, roles []string) error
+	InsertCompanies(ctx context.Context, companies []*service_models.Company) error
+	InsertCompanyMembers(ctx context.Context, members []*service_models.CompanyMember) error
+	InsertJobs(ctx context.Context, jobs []*service_models.Job) error
+	GrantPosterRole(ctx context.Context, role string) error
+	PurgeSeedData(ctx context.Context) (*service_models.SeedResult, error)
+	GetWithTXT(tx *sql.Tx) Seed
+}
+
+type seedRepository struct {
+	dbWrite *sql.DB
+	dbRead  *sql.DB
+	tx      *sql.Tx
+}
+
+func (s *seedRepository) HasSeedData(ctx context.Context) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM users WHERE seeded) OR EXISTS (SELECT 1 FROM companies WHERE seeded)`
+	var exists bool
+	if err := s.write(ctx).QueryRowContext(ctx, query).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// InsertUsers copies users in and gives all of them roles. The users get their
+// IDs from the users sequence.
+func (s *seedRepository) InsertUsers(ctx context.Context, users []*service_models.User, roles []string) error {
+	if s.tx == nil {
+		return ErrTxRequired
+	}
+	ids, err := s.reserveIDs(ctx, "users", len(users))
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]any, len(users))
+	for i, user := range users {
+		user.ID = ids[i]
+		rows[i] = []any{user.ID, user.Username, user.Password, user.Email, user.EmailVerifiedAt, user.CreateAt, user.CreateAt, true}
+	}
+	if err = s.copyRows(ctx, "users", []string{"id", "username", "password", "email", "email_verified_at", "created_at", "updated_at", "seeded"}, rows); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO user_roles (user_id, role_id)
+		SELECT u.id, roles.id FROM unnest($1::bigint[]) AS u(id), roles WHERE roles.name = ANY($2)`
+	if _, err = s.tx.ExecContext(ctx, query, pq.Array(ids), pq.Array(roles)); err != nil {
+		return err
+	}
+	for _, user := range users {
+		user.Roles = roles
+	}
+	return nil
+}
+
+func (s *seedRepository) InsertCompanies(ctx context.Context, companies []*service_models.Company) error {
+	if s.tx == nil {
+		return ErrTxRequired
+	}
+	ids, err := s.reserveIDs(ctx, "companies", len(companies))
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]any, len(companies))
+	for i, company := range companies {
+		company.ID = ids[i]
+		rows[i] = []any{company.ID, company.Name, company.Slug, company.Website, company.Description, company.CreatedAt, company.UpdatedAt, true}
+	}
+	return s.copyRows(ctx, "companies", []string{"id", "name", "slug", "website", "description", "created_at", "updated_at", "seeded"}, rows)
+}
+
+func (s *seedRepository) InsertCompanyMembers(ctx context.Context, members []*service_models.CompanyMember) error {
+	if s.tx == nil {
+		return ErrTxRequired
+	}
+	rows := make([][]any, len(members))
+	for i, member := range members {
+		rows[i] = []any{member.CompanyID, member.UserID, member.Role, member.CreatedAt}
+	}
+	return s.copyRows(ctx, "company_members", []string{"company_id", "user_id", "role", "created_at"}, rows)
+}
+
+// InsertJobs copies jobs in and tags them with those of their tags that exist.
+func (s *seedRepository) InsertJobs(ctx context.Context, jobs []*service_models.Job) error {
+	if s.tx == nil {
+		return ErrTxRequired
+	}
+	ids, err := s.reserveIDs(ctx, "jobs", len(jobs))
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]any, len(jobs))
+	var tagJobIDs []int64
+	var tagNames []string
+	for i, job := range jobs {
+		job.ID = ids[i]
+		rows[i] = []any{job.ID, job.Title, job.Description, job.Location, job.Company, job.CompanyID, job.Salary, job.SalaryMin, job.SalaryMax,
+			job.SalaryCurrency, job.SalaryPeriod, job.Status, job.ExpiresAt, job.PublishedAt, job.ClosedAt, job.CreatedAt, job.UserID, true}
+		for _, tag := range job.Tags {
+			tagJobIDs = append(tagJobIDs, job.ID)
+			tagNames = append(tagNames, tag)
+		}
+	}
+	columns := []string{"id", "title", "description", "location", "company", "company_id", "salary", "salary_min", "salary_max",
+		"salary_currency", "salary_period", "status", "expires_at", "published_at", "closed_at", "created_at", "user_id", "seeded"}
+	if err = s.copyRows(ctx, "jobs", columns, rows); err != nil {
+		return err
+	}
+
+	if len(tagJobIDs) == 0 {
+		return nil
+	}
+	query := `
+		INSERT INTO job_tags (job_id, tag_id)
+		SELECT jt.job_id, tags.id FROM unnest($1::bigint[], $2::text[]) AS jt(job_id, name) JOIN tags ON tags.name = jt.name
+		ON CONFLICT DO NOTHING`
+	_, err = s.tx.ExecContext(ctx, query, pq.Array(tagJobIDs), pq.Array(tagNames))
+	return err
+}
+
+// GrantPosterRole gives role to the seeded users who belong to a company or
+// have posted a job.
+func (s *seedRepository) GrantPosterRole(ctx context.Context, role string) error {
+	if s.tx == nil {
+		return ErrTxRequired
+	}
+	query := `
+		INSERT INTO user_roles (user_id, role_id)
+		SELECT u.id, r.id FROM users u CROSS JOIN roles r
+		WHERE u.seeded AND r.name = $1
+			AND (EXISTS (SELECT 1 FROM jobs j WHERE j.user_id = u.id) OR EXISTS (SELECT 1 FROM company_members cm WHERE cm.user_id = u.id))
+		ON CONFLICT DO NOTHING`
+	_, err := s.tx.ExecContext(ctx, query, role)
+	return err
+}
+
+// PurgeSeedData deletes seeded jobs, companies and users, in that order
+// because jobs do not cascade with their poster. Jobs posted by seeded users,
+// for example while trying the API as one of them, go as well, since the
+// users could not be deleted otherwise. Applications to deleted jobs go with
+// them.
+func (s *seedRepository) PurgeSeedData(ctx context.Context) (*service_models.SeedResult, error) {
+	if s.tx == nil {
+		return nil, ErrTxRequired
+	}
+	result := &service_models.SeedResult{}
+	statements := []struct {
+		query string
+		count *int64
+	}{
+		{`DELETE FROM jobs WHERE seeded OR user_id IN (SELECT id FROM users WHERE seeded)`, &result.Jobs},
+		{`DELETE FROM companies WHERE seeded`, &result.Companies},
+		{`DELETE FROM users WHERE seeded`, &result.Users},
+	}
+	for _, statement := range statements {
+		res, err := s.tx.ExecContext(ctx, statement.query)
+		if err != nil {
+			return nil, err
+		}
+		if *statement.count, err = res.RowsAffected(); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// reserveIDs takes n values from the ID sequence of table, so rows copied in
+// with them can be referenced straight away.
+func (s *seedRepository) reserveIDs(ctx context.Context, table string, n int) ([]int64, error) {
+	query := `SELECT nextval(pg_get_serial_sequence($1, 'id')) FROM generate_series(1, $2)`
+	rows, err := s.tx.QueryContext(ctx, query, table, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make([]int64, 0, n)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *seedRepository) copyRows(ctx context.Context, table string, columns []string, rows [][]any) error {
+	stmt, err := s.tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		if _, err = stmt.ExecContext(ctx, row...); err != nil {
+			return err
+		}
+	}
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
+func (s *seedRepository) write(ctx context.Context) Querier {
+	return writeQuerier(ctx, s.dbWrite, s.tx)
+}
+
+func (s *seedRepository) GetWithTXT(tx *sql.Tx) Seed {
+	return &seedRepository{
+		dbWrite: s.dbWrite,
+		dbRead:  s.dbRead,
+		tx:      tx,
+	}
+}
+
+func NewSeedRepository(dbWrite, dbRead *sql.DB) Seed {
+	return &seedRepository{
+		dbWrite: dbWrite,
+		dbRead:  dbRead,
+		tx:      nil,
+	}
+}
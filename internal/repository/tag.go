@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+)
+
+type Tag interface {
+	CreateTag(ctx context.Context, tag *service_models.Tag) (*service_models.Tag, error)
+	GetTagById(ctx context.Context, id int64) (*service_models.Tag, error)
+	GetAllTags(ctx context.Context) ([]*service_models.Tag, error)
+	GetMissingTags(ctx context.Context, names []string) ([]string, error)
+	UpdateTag(ctx context.Context, tag *service_models.Tag) (*service_models.Tag, error)
+	DeleteTag(ctx context.Context, id int64) error
+	GetWithTXT(tx *sql.Tx) Tag
+}
+
+// tagColumns selects a tag with the number of open jobs using it.
+var tagColumns = fmt.Sprintf(`id, name, (SELECT count(*) FROM job_tags jt JOIN jobs ON jobs.id = jt.job_id WHERE jt.tag_id = tags.id AND %s), created_at`, publishedJobCondition)
+
+type tagRepository struct {
+	dbWrite *sql.DB
+	dbRead  *sql.DB
+	tx      *sql.Tx
+}
+
+func (t *tagRepository) CreateTag(ctx context.Context, tag *service_models.Tag) (*service_models.Tag, error) {
+	query := `INSERT INTO tags (name) VALUES ($1) RETURNING id, created_at`
+	if err := t.write(ctx).QueryRowContext(ctx, query, tag.Name).Scan(&tag.ID, &tag.CreatedAt); err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "tags_name_key"`:
+			return nil, ErrDuplicateTag
+		default:
+			return nil, err
+		}
+	}
+	return tag, nil
+}
+
+func (t *tagRepository) GetTagById(ctx context.Context, id int64) (*service_models.Tag, error) {
+	query := fmt.Sprintf(`SELECT %s FROM tags WHERE id = $1`, tagColumns)
+	tag, err := scanTag(t.read(ctx).QueryRowContext(ctx, query, id))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return tag, nil
+}
+
+func (t *tagRepository) GetAllTags(ctx context.Context) ([]*service_models.Tag, error) {
+	query := fmt.Sprintf(`SELECT %s FROM tags ORDER BY 3 DESC, name`, tagColumns)
+	rows, err := t.read(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tags []*service_models.Tag
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// GetMissingTags returns the names that do not match an existing tag.
+func (t *tagRepository) GetMissingTags(ctx context.Context, names []string) ([]string, error) {
+	var missing []string
+	query := `SELECT ARRAY(SELECT n FROM unnest($1::text[]) AS n WHERE NOT EXISTS (SELECT 1 FROM tags WHERE name = n) ORDER BY n)`
+	if err := t.read(ctx).QueryRowContext(ctx, query, pq.Array(names)).Scan(pq.Array(&missing)); err != nil {
+		return nil, err
+	}
+	return missing, nil
+}
+
+func (t *tagRepository) UpdateTag(ctx context.Context, tag *service_models.Tag) (*service_models.Tag, error) {
+	query := fmt.Sprintf(`UPDATE tags SET name = $1 WHERE id = $2 RETURNING %s`, tagColumns)
+	updatedTag, err := scanTag(t.write(ctx).QueryRowContext(ctx, query, tag.Name, tag.ID))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		case err.Error() == `pq: duplicate key value violates unique constraint "tags_name_key"`:
+			return nil, ErrDuplicateTag
+		default:
+			return nil, err
+		}
+	}
+	return updatedTag, nil
+}
+
+func (t *tagRepository) DeleteTag(ctx context.Context, id int64) error {
+	query := `DELETE FROM tags WHERE id = $1`
+	res, err := t.write(ctx).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (t *tagRepository) write(ctx context.Context) Querier {
+	return writeQuerier(ctx, t.dbWrite, t.tx)
+}
+
+func (t *tagRepository) read(ctx context.Context) Querier {
+	return readQuerier(ctx, t.dbWrite, t.dbRead, t.tx)
+}
+
+func (t *tagRepository) GetWithTXT(tx *sql.Tx) Tag {
+	return &tagRepository{
+		dbWrite: t.dbWrite,
+		dbRead:  t.dbRead,
+		tx:      tx,
+	}
+}
+
+func NewTagRepository(dbWrite *sql.DB, dbRead *sql.DB) Tag {
+	return &tagRepository{
+		dbWrite: dbWrite,
+		dbRead:  dbRead,
+	}
+}
+
+func scanTag(row rowScanner) (*service_models.Tag, error) {
+	var tag service_models.Tag
+	if err := row.Scan(&tag.ID, &tag.Name, &tag.JobCount, &tag.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
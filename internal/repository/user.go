@@ -5,33 +5,58 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/lib/pq"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
-	"golang.org/x/crypto/bcrypt"
+	"time"
 )
 
 type User interface {
 	CreateUser(ctx context.Context, user *service_models.User) error
 	GetUserById(ctx context.Context, id int64) (*service_models.User, error)
 	GetUserByUsername(ctx context.Context, username string) (*service_models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*service_models.User, error)
 	UpdateUserProfile(ctx context.Context, user *service_models.User) (*service_models.User, error)
 	UpdateUserProfilePicture(ctx context.Context, id int64, picture string) error
 	GetAllUsers(ctx context.Context) ([]*service_models.User, error)
 	UpdateUserPassword(ctx context.Context, user *service_models.User) error
 	DeleteUser(ctx context.Context, id int64) (string, error)
-	ChangePassword(ctx context.Context, id int64, currentPassword, newPassword string) error
+	GetPasswordHashForUpdate(ctx context.Context, id int64) (string, error)
+	GetAuthState(ctx context.Context, id int64, mfa bool) (*service_models.AuthState, error)
+	ReserveVerificationEmail(ctx context.Context, id int64, interval time.Duration) error
+	MarkEmailVerified(ctx context.Context, id int64, email string) error
 	GetWithTXT(tx *sql.Tx) User
 }
 
+// revokeTokensOnPasswordChange prefixes password updates so that changing a
+// password also ends every session of the user. $2 is the user ID.
+const revokeTokensOnPasswordChange = `
+	WITH revoked AS (
+		UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $2 AND revoked_at IS NULL
+	)
+	`
+
+// userRolesColumn selects the names of the user's roles as a text array.
+const userRolesColumn = `ARRAY(SELECT r.name FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = users.id ORDER BY r.name)`
+
 type userRepository struct {
 	dbWrite *sql.DB
 	dbRead  *sql.DB
 	tx      *sql.Tx
 }
 
+// CreateUser inserts the user together with the roles named in user.Roles.
+// Names that match no role are skipped.
 func (u *userRepository) CreateUser(ctx context.Context, user *service_models.User) error {
-	query := `INSERT INTO users(username,password,email) VALUES ($1,$2,$3) RETURNING id, created_at`
+	query := `
+		WITH created AS (
+			INSERT INTO users(username,password,email) VALUES ($1,$2,$3) RETURNING id, created_at
+		), assigned AS (
+			INSERT INTO user_roles (user_id, role_id)
+			SELECT created.id, roles.id FROM created, roles WHERE roles.name = ANY($4)
+		)
+		SELECT id, created_at FROM created`
 
-	err := u.dbWrite.QueryRowContext(ctx, query, user.Username, user.Password, user.Email).Scan(&user.ID, &user.CreateAt)
+	err := u.write(ctx).QueryRowContext(ctx, query, user.Username, user.Password, user.Email, pq.Array(user.Roles)).Scan(&user.ID, &user.CreateAt)
 	if err != nil {
 		switch {
 		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
@@ -48,9 +73,9 @@ func (u *userRepository) CreateUser(ctx context.Context, user *service_models.Us
 func (u *userRepository) GetUserById(ctx context.Context, id int64) (*service_models.User, error) {
 	var user service_models.User
 	var profilePicture sql.NullString
-	query := `SELECT id, username, password, email, created_at, updated_at, is_admin, profile_picture FROM users WHERE id = $1`
+	query := fmt.Sprintf(`SELECT id, username, COALESCE(password, ''), email, created_at, updated_at, %s, profile_picture, email_verified_at, verification_sent_at, totp_enabled_at IS NOT NULL FROM users WHERE id = $1`, userRolesColumn)
 
-	err := u.dbRead.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.CreateAt, &user.UpdateAt, &user.IsAdmin, &profilePicture)
+	err := u.read(ctx).QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.CreateAt, &user.UpdateAt, pq.Array(&user.Roles), &profilePicture, &user.EmailVerifiedAt, &user.VerificationSentAt, &user.MFAEnabled)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -69,9 +94,10 @@ func (u *userRepository) GetUserById(ctx context.Context, id int64) (*service_mo
 
 func (u *userRepository) GetUserByUsername(ctx context.Context, username string) (*service_models.User, error) {
 	var user service_models.User
-	query := `SELECT id, username, password, email, created_at, updated_at, is_admin, profile_picture FROM users WHERE username = $1`
+	query := fmt.Sprintf(`SELECT id, username, COALESCE(password, ''), email, created_at, updated_at, %s, profile_picture, email_verified_at, verification_sent_at, totp_enabled_at IS NOT NULL FROM users WHERE username = $1`, userRolesColumn)
 
-	err := u.dbRead.QueryRowContext(ctx, query, username).Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.CreateAt, &user.UpdateAt, &user.IsAdmin, &user.ProfilePicture)
+	// Logins check the password hash, which must not be stale.
+	err := u.primary().QueryRowContext(ctx, query, username).Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.CreateAt, &user.UpdateAt, pq.Array(&user.Roles), &user.ProfilePicture, &user.EmailVerifiedAt, &user.VerificationSentAt, &user.MFAEnabled)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -84,13 +110,41 @@ func (u *userRepository) GetUserByUsername(ctx context.Context, username string)
 	return &user, err
 }
 
+func (u *userRepository) GetUserByEmail(ctx context.Context, email string) (*service_models.User, error) {
+	var user service_models.User
+	query := fmt.Sprintf(`SELECT id, username, COALESCE(password, ''), email, created_at, updated_at, %s, profile_picture, email_verified_at, verification_sent_at, totp_enabled_at IS NOT NULL FROM users WHERE email = $1`, userRolesColumn)
+
+	err := u.read(ctx).QueryRowContext(ctx, query, email).Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.CreateAt, &user.UpdateAt, pq.Array(&user.Roles), &user.ProfilePicture, &user.EmailVerifiedAt, &user.VerificationSentAt, &user.MFAEnabled)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &user, nil
+}
+
+// UpdateUserProfile clears the verification state when the email changes, so
+// the new address has to be verified again.
 func (u *userRepository) UpdateUserProfile(ctx context.Context, user *service_models.User) (*service_models.User, error) {
-	query := `UPDATE users SET username = $1, email = $2 WHERE id = $3`
-	_, err := u.dbWrite.ExecContext(ctx, query, user.Username, user.Email, user.ID)
+	query := `
+		UPDATE users SET username = $1, email = $2,
+			email_verified_at = CASE WHEN email = $2 THEN email_verified_at END,
+			verification_sent_at = CASE WHEN email = $2 THEN verification_sent_at END
+		WHERE id = $3
+		RETURNING email_verified_at, verification_sent_at, totp_enabled_at IS NOT NULL`
+	err := u.write(ctx).QueryRowContext(ctx, query, user.Username, user.Email, user.ID).Scan(&user.EmailVerifiedAt, &user.VerificationSentAt, &user.MFAEnabled)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
 			return nil, ErrRecordNotFound
+		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
+			return nil, ErrDuplicateEmails
+		case err.Error() == `pq: duplicate key value violates unique constraint "users_username_key"`:
+			return nil, ErrDuplicateUsernames
 		default:
 			return nil, err
 		}
@@ -100,7 +154,7 @@ func (u *userRepository) UpdateUserProfile(ctx context.Context, user *service_mo
 
 func (u *userRepository) UpdateUserProfilePicture(ctx context.Context, id int64, picture string) error {
 	query := `UPDATE users SET profile_picture = $1 WHERE id = $2`
-	_, err := u.dbWrite.ExecContext(ctx, query, picture, id)
+	_, err := u.write(ctx).ExecContext(ctx, query, picture, id)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -114,8 +168,8 @@ func (u *userRepository) UpdateUserProfilePicture(ctx context.Context, id int64,
 
 func (u *userRepository) GetAllUsers(ctx context.Context) ([]*service_models.User, error) {
 	var users []*service_models.User
-	query := `SELECT id, username, password, email, created_at, updated_at, is_admin, profile_picture FROM users`
-	rows, err := u.dbRead.QueryContext(ctx, query)
+	query := fmt.Sprintf(`SELECT id, username, COALESCE(password, ''), email, created_at, updated_at, %s, profile_picture, email_verified_at, verification_sent_at, totp_enabled_at IS NOT NULL FROM users`, userRolesColumn)
+	rows, err := u.read(ctx).QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +177,7 @@ func (u *userRepository) GetAllUsers(ctx context.Context) ([]*service_models.Use
 	for rows.Next() {
 		var user service_models.User
 		var profilePicture sql.NullString
-		err = rows.Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.CreateAt, &user.UpdateAt, &user.IsAdmin, &profilePicture)
+		err = rows.Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.CreateAt, &user.UpdateAt, pq.Array(&user.Roles), &profilePicture, &user.EmailVerifiedAt, &user.VerificationSentAt, &user.MFAEnabled)
 		if err != nil {
 			return nil, err
 		}
@@ -138,85 +192,150 @@ func (u *userRepository) GetAllUsers(ctx context.Context) ([]*service_models.Use
 }
 
 func (u *userRepository) UpdateUserPassword(ctx context.Context, user *service_models.User) error {
-	query := `UPDATE users SET password = $1 WHERE id = $2`
-	_, err := u.dbWrite.ExecContext(ctx, query, user.Password, user.ID)
+	query := revokeTokensOnPasswordChange + `UPDATE users SET password = $1, tokens_valid_after = NOW() WHERE id = $2`
+	result, err := u.write(ctx).ExecContext(ctx, query, user.Password, user.ID)
 	if err != nil {
-		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			return ErrRecordNotFound
-		default:
-			return err
-		}
-	}
-	return nil
-}
-
-func (u *userRepository) DeleteUser(ctx context.Context, id int64) (string, error) {
-	query := `DELETE FROM users WHERE id = $1`
-	result, err := u.dbWrite.ExecContext(ctx, query, id)
-	if err != nil {
-		return "", fmt.Errorf("error deleting user: %v", err)
+		return err
 	}
-
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return "", fmt.Errorf("error getting rows affected: %v", err)
+		return err
 	}
 	if rowsAffected == 0 {
-		return "", ErrRecordNotFound
+		return ErrRecordNotFound
 	}
+	return nil
+}
 
-	var profilePicture sql.NullString
-	query = `SELECT profile_picture FROM users WHERE id = $1`
-	err = u.dbRead.QueryRowContext(ctx, query, id).Scan(&profilePicture)
+// DeleteUser deletes the user and returns the name of their profile picture,
+// or an empty string when they had none.
+func (u *userRepository) DeleteUser(ctx context.Context, id int64) (string, error) {
+	var profilePicture string
+	query := `DELETE FROM users WHERE id = $1 RETURNING COALESCE(profile_picture, '')`
+	err := u.write(ctx).QueryRowContext(ctx, query, id).Scan(&profilePicture)
 	if err != nil {
-
-		if errors.Is(err, sql.ErrNoRows) {
-			return "", nil
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return "", ErrRecordNotFound
+		default:
+			return "", fmt.Errorf("error deleting user: %w", err)
 		}
-		return "", fmt.Errorf("error retrieving profile picture: %v", err)
 	}
-
-	return profilePicture.String, nil
+	return profilePicture, nil
 }
 
-func (u *userRepository) ChangePassword(ctx context.Context, id int64, currentPassword, newPassword string) error {
+// GetPasswordHashForUpdate returns the user's password hash, or an empty
+// string for users without a password. Inside a transaction the row stays
+// locked until it ends, so the password cannot change in between.
+func (u *userRepository) GetPasswordHashForUpdate(ctx context.Context, id int64) (string, error) {
 	var hashedPassword string
+	query := `SELECT COALESCE(password, '') FROM users WHERE id = $1 FOR UPDATE`
+	err := u.write(ctx).QueryRowContext(ctx, query, id).Scan(&hashedPassword)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return "", ErrRecordNotFound
+		default:
+			return "", err
+		}
+	}
+	return hashedPassword, nil
+}
 
-	query := `SELECT password FROM users WHERE id = $1`
-	err := u.dbRead.QueryRowContext(ctx, query, id).Scan(&hashedPassword)
+// GetAuthState returns when the user's tokens were last revoked and the
+// permissions granted by their roles. While the security policy requires
+// two-factor authentication for admins, the admin role grants nothing to a
+// session started without it.
+func (u *userRepository) GetAuthState(ctx context.Context, id int64, mfa bool) (*service_models.AuthState, error) {
+	var state service_models.AuthState
+	query := `
+		SELECT tokens_valid_after, ARRAY(
+			SELECT DISTINCT p.name
+			FROM user_roles ur
+			JOIN roles r ON r.id = ur.role_id
+			JOIN role_permissions rp ON rp.role_id = r.id
+			JOIN permissions p ON p.id = rp.permission_id
+			WHERE ur.user_id = users.id
+				AND ($2 OR r.name <> $3 OR NOT COALESCE((SELECT require_admin_mfa FROM security_settings), false))
+			ORDER BY p.name)
+		FROM users WHERE id = $1`
+	// Revocations and role changes must apply at once, whatever the replica lag.
+	err := u.primary().QueryRowContext(ctx, query, id, mfa, service_models.RoleAdmin).Scan(&state.TokensValidAfter, pq.Array(&state.Permissions))
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return ErrRecordNotFound
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
 		}
-		return fmt.Errorf("error checking password: %v", err)
 	}
+	return &state, nil
+}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(currentPassword)); err != nil {
-		return fmt.Errorf("current password is incorrect: %v", err)
+// ReserveVerificationEmail records that a verification email is about to be
+// sent. It returns ErrVerificationLimited when one was sent less than interval
+// ago and ErrEmailAlreadyVerified when there is nothing to verify.
+func (u *userRepository) ReserveVerificationEmail(ctx context.Context, id int64, interval time.Duration) error {
+	query := `
+		UPDATE users SET verification_sent_at = NOW()
+		WHERE id = $1 AND email_verified_at IS NULL
+			AND (verification_sent_at IS NULL OR verification_sent_at <= NOW() - make_interval(secs => $2))
+		RETURNING id`
+	var userID int64
+	err := u.write(ctx).QueryRowContext(ctx, query, id, interval.Seconds()).Scan(&userID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
 	}
 
-	hashedNewPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
-	if err != nil {
-		return fmt.Errorf("error generating new password hash: %v", err)
+	var verified bool
+	query = `SELECT email_verified_at IS NOT NULL FROM users WHERE id = $1`
+	if err = u.primary().QueryRowContext(ctx, query, id).Scan(&verified); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
 	}
+	if verified {
+		return ErrEmailAlreadyVerified
+	}
+	return ErrVerificationLimited
+}
 
-	query = `UPDATE users SET password = $1 WHERE id = $2`
-	result, err := u.dbWrite.ExecContext(ctx, query, hashedNewPassword, id)
+// MarkEmailVerified verifies the user's email, provided it is still the
+// address the verification link was sent to. Verifying twice is not an error.
+func (u *userRepository) MarkEmailVerified(ctx context.Context, id int64, email string) error {
+	query := `UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE id = $1 AND email = $2`
+	res, err := u.write(ctx).ExecContext(ctx, query, id, email)
 	if err != nil {
-		return fmt.Errorf("error updating password: %v", err)
+		return err
 	}
-
-	rowsAffected, err := result.RowsAffected()
+	rows, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("error checking update result: %v", err)
+		return err
 	}
-	if rowsAffected == 0 {
+	if rows == 0 {
 		return ErrRecordNotFound
 	}
 	return nil
 }
 
+func (u *userRepository) write(ctx context.Context) Querier {
+	return writeQuerier(ctx, u.dbWrite, u.tx)
+}
+
+func (u *userRepository) read(ctx context.Context) Querier {
+	return readQuerier(ctx, u.dbWrite, u.dbRead, u.tx)
+}
+
+func (u *userRepository) primary() Querier {
+	return primaryQuerier(u.dbWrite, u.tx)
+}
+
 func (u *userRepository) GetWithTXT(tx *sql.Tx) User {
 	return &userRepository{
 		dbWrite: u.dbWrite,
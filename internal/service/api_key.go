@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"github.com/saleh-ghazimoradi/GoJobs/logger"
+	"github.com/saleh-ghazimoradi/GoJobs/utils"
+	"slices"
+	"strings"
+	"time"
+)
+
+type APIKey interface {
+	CreateAPIKey(ctx context.Context, key *service_models.APIKey, granted []string) (*service_models.CreatedAPIKey, error)
+	GetAllAPIKeys(ctx context.Context, userID int64) ([]*service_models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id, userID int64) error
+	Authenticate(ctx context.Context, key string) (*service_models.Principal, error)
+	GetWithTXT(tx *sql.Tx) APIKey
+}
+
+type apiKeyService struct {
+	apiKeyRepo repository.APIKey
+	userRepo   repository.User
+}
+
+// CreateAPIKey generates a key for key.UserID. granted are the permissions of
+// the caller; every scope must be one of them, so a key never does more than
+// the session that created it.
+func (a *apiKeyService) CreateAPIKey(ctx context.Context, key *service_models.APIKey, granted []string) (*service_models.CreatedAPIKey, error) {
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+		return nil, repository.ErrInvalidExpiry
+	}
+
+	key.Scopes = normalizeNames(key.Scopes)
+	for _, scope := range key.Scopes {
+		if !slices.Contains(granted, scope) {
+			return nil, repository.ErrInvalidScope
+		}
+	}
+
+	id, err := utils.GenerateRandomToken(6)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Name = strings.TrimSpace(key.Name)
+	key.Prefix = service_models.APIKeyPrefix + id
+	plaintext := key.Prefix + "_" + secret
+
+	if err = a.apiKeyRepo.CreateAPIKey(ctx, key, utils.HashToken(plaintext), config.AppConfig.APIKeys.MaxPerUser); err != nil {
+		return nil, err
+	}
+	return &service_models.CreatedAPIKey{APIKey: key, Key: plaintext}, nil
+}
+
+func (a *apiKeyService) GetAllAPIKeys(ctx context.Context, userID int64) ([]*service_models.APIKey, error) {
+	return a.apiKeyRepo.GetAllAPIKeys(ctx, userID)
+}
+
+func (a *apiKeyService) RevokeAPIKey(ctx context.Context, id, userID int64) error {
+	return a.apiKeyRepo.RevokeAPIKey(ctx, id, userID)
+}
+
+// Authenticate resolves an API key to its owner. The key acts with the
+// owner's current permissions limited to its scopes, and never counts as a
+// two-factor session.
+func (a *apiKeyService) Authenticate(ctx context.Context, key string) (*service_models.Principal, error) {
+	if !strings.HasPrefix(key, service_models.APIKeyPrefix) {
+		return nil, repository.ErrInvalidAPIKey
+	}
+
+	stored, err := a.apiKeyRepo.GetAPIKeyByHash(ctx, utils.HashToken(key))
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, repository.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if stored.RevokedAt != nil || (stored.ExpiresAt != nil && !stored.ExpiresAt.After(time.Now())) {
+		return nil, repository.ErrInvalidAPIKey
+	}
+
+	state, err := a.userRepo.GetAuthState(ctx, stored.UserID, false)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, repository.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	if err = a.apiKeyRepo.TouchAPIKey(ctx, stored.ID); err != nil {
+		logger.Logger.Error("unable to record API key use", "api_key_id", stored.ID, "error", err.Error())
+	}
+
+	permissions := []string{}
+	for _, permission := range state.Permissions {
+		if slices.Contains(stored.Scopes, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+
+	return &service_models.Principal{
+		UserID:      stored.UserID,
+		Permissions: permissions,
+		APIKeyID:    &stored.ID,
+		Scopes:      stored.Scopes,
+	}, nil
+}
+
+func (a *apiKeyService) GetWithTXT(tx *sql.Tx) APIKey {
+	return &apiKeyService{
+		apiKeyRepo: a.apiKeyRepo.GetWithTXT(tx),
+		userRepo:   a.userRepo.GetWithTXT(tx),
+	}
+}
+
+func NewAPIKeyService(apiKeyRepo repository.APIKey, userRepo repository.User) APIKey {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+	}
+}
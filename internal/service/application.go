@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"time"
+)
+
+type Application interface {
+	ApplyToJob(ctx context.Context, application *service_models.Application) (*service_models.Application, error)
+	CheckApplication(ctx context.Context, application *service_models.Application) error
+	GetApplicationById(ctx context.Context, id int64, userID int64, canManageAll bool) (*service_models.Application, error)
+	GetAllApplicationsByJobID(ctx context.Context, jobID int64, userID int64, canManageAll bool) ([]*service_models.Application, error)
+	GetAllApplicationsByUserID(ctx context.Context, userID int64) ([]*service_models.Application, error)
+	ChangeStage(ctx context.Context, id int64, stage service_models.ApplicationStage, note string, userID int64, canManageAll bool) (*service_models.Application, error)
+	GetApplicationHistory(ctx context.Context, id int64, userID int64, canManageAll bool) ([]*service_models.ApplicationHistory, error)
+	GetWithTXT(tx *sql.Tx) Application
+}
+
+// applicationStageTransitions lists the stages an application can move to from
+// each stage. Hired and rejected are final.
+var applicationStageTransitions = map[service_models.ApplicationStage][]service_models.ApplicationStage{
+	service_models.StageApplied:   {service_models.StageScreening, service_models.StageInterview, service_models.StageRejected},
+	service_models.StageScreening: {service_models.StageInterview, service_models.StageRejected},
+	service_models.StageInterview: {service_models.StageOffer, service_models.StageRejected},
+	service_models.StageOffer:     {service_models.StageHired, service_models.StageRejected},
+}
+
+func canTransition(from, to service_models.ApplicationStage) bool {
+	for _, next := range applicationStageTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+type applicationService struct {
+	applicationRepo repository.Application
+	jobRepo         repository.Job
+	companyRepo     repository.Company
+}
+
+func (a *applicationService) ApplyToJob(ctx context.Context, application *service_models.Application) (*service_models.Application, error) {
+	if err := a.CheckApplication(ctx, application); err != nil {
+		return nil, err
+	}
+	return a.applicationRepo.CreateApplication(ctx, application)
+}
+
+// CheckApplication reports whether the user may apply to the job, so that a
+// resume is only stored for an application that can be submitted.
+func (a *applicationService) CheckApplication(ctx context.Context, application *service_models.Application) error {
+	job, err := a.jobRepo.GetJobById(ctx, application.JobID)
+	if err != nil {
+		return err
+	}
+
+	switch err = canManageJob(ctx, a.companyRepo, job, application.UserID, false); {
+	case err == nil:
+		return repository.ErrOwnJobApplication
+	case !errors.Is(err, repository.ErrUnAuthorized):
+		return err
+	}
+
+	if !job.IsOpen(time.Now()) {
+		return repository.ErrJobNotOpen
+	}
+	return nil
+}
+
+func (a *applicationService) GetApplicationById(ctx context.Context, id int64, userID int64, canManageAll bool) (*service_models.Application, error) {
+	application, err := a.applicationRepo.GetApplicationById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if canManageAll || application.UserID == userID {
+		return application, nil
+	}
+
+	job, err := a.jobRepo.GetJobById(ctx, application.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = canManageJob(ctx, a.companyRepo, job, userID, canManageAll); err != nil {
+		return nil, err
+	}
+	return application, nil
+}
+
+func (a *applicationService) GetAllApplicationsByJobID(ctx context.Context, jobID int64, userID int64, canManageAll bool) ([]*service_models.Application, error) {
+	job, err := a.jobRepo.GetJobById(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = canManageJob(ctx, a.companyRepo, job, userID, canManageAll); err != nil {
+		return nil, err
+	}
+
+	return a.applicationRepo.GetAllApplicationsByJobID(ctx, jobID)
+}
+
+func (a *applicationService) GetAllApplicationsByUserID(ctx context.Context, userID int64) ([]*service_models.Application, error) {
+	return a.applicationRepo.GetAllApplicationsByUserID(ctx, userID)
+}
+
+func (a *applicationService) ChangeStage(ctx context.Context, id int64, stage service_models.ApplicationStage, note string, userID int64, canManageAll bool) (*service_models.Application, error) {
+	application, err := a.applicationRepo.GetApplicationById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := a.jobRepo.GetJobById(ctx, application.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = canManageJob(ctx, a.companyRepo, job, userID, canManageAll); err != nil {
+		return nil, err
+	}
+
+	if !canTransition(application.Stage, stage) {
+		return nil, fmt.Errorf("%w: cannot move from %s to %s", repository.ErrInvalidStage, application.Stage, stage)
+	}
+
+	history, err := a.applicationRepo.UpdateApplicationStage(ctx, id, application.Stage, stage, userID, note)
+	if err != nil {
+		return nil, err
+	}
+
+	application.Stage = stage
+	application.UpdatedAt = history.CreatedAt
+	return application, nil
+}
+
+func (a *applicationService) GetApplicationHistory(ctx context.Context, id int64, userID int64, canManageAll bool) ([]*service_models.ApplicationHistory, error) {
+	if _, err := a.GetApplicationById(ctx, id, userID, canManageAll); err != nil {
+		return nil, err
+	}
+	return a.applicationRepo.GetApplicationHistory(ctx, id)
+}
+
+func (a *applicationService) GetWithTXT(tx *sql.Tx) Application {
+	return &applicationService{
+		applicationRepo: a.applicationRepo.GetWithTXT(tx),
+		jobRepo:         a.jobRepo.GetWithTXT(tx),
+		companyRepo:     a.companyRepo.GetWithTXT(tx),
+	}
+}
+
+func NewApplicationService(applicationRepo repository.Application, jobRepo repository.Job, companyRepo repository.Company) Application {
+	return &applicationService{
+		applicationRepo: applicationRepo,
+		jobRepo:         jobRepo,
+		companyRepo:     companyRepo,
+	}
+}
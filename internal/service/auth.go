@@ -3,70 +3,473 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"github.com/saleh-ghazimoradi/GoJobs/logger"
+	"github.com/saleh-ghazimoradi/GoJobs/mailer"
 	"github.com/saleh-ghazimoradi/GoJobs/utils"
 	"golang.org/x/crypto/bcrypt"
+	"net/url"
+	"time"
 )
 
 type Authenticate interface {
 	RegisterUser(ctx context.Context, user *service_models.User) error
-	LoginUser(ctx context.Context, username, password string) (string, error)
-	ForgotPassword(ctx context.Context, username string) (string, error)
+	LoginUser(ctx context.Context, username, password, ip, locale string) (*service_models.LoginResult, error)
+	LoginAuthenticatedUser(ctx context.Context, user *service_models.User) (*service_models.LoginResult, error)
+	CompleteMFALogin(ctx context.Context, mfaToken, code string) (*service_models.TokenPair, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*service_models.TokenPair, error)
+	Logout(ctx context.Context, refreshToken string, allDevices bool) error
+	ValidateAccessToken(ctx context.Context, token string) (*service_models.Principal, error)
+	ForgotPassword(ctx context.Context, username, locale string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	UnlockAccount(ctx context.Context, token string) error
+	SetPassword(ctx context.Context, userID int64, password string) error
 	GetWithTXT(tx *sql.Tx) Authenticate
 }
 
 type authService struct {
-	userRepo repository.User
+	userRepo          repository.User
+	refreshTokenRepo  repository.RefreshToken
+	passwordResetRepo repository.PasswordReset
+	loginRepo         repository.Login
+	securityEventRepo repository.SecurityEvent
+	mfa               MFA
+	mailer            mailer.Mailer
 }
 
+// dummyPasswordHash is compared against when the username does not exist, so
+// that a login for an unknown user takes as long as one with a wrong password.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("gojobs-dummy-password"), bcrypt.DefaultCost)
+
 func (a *authService) RegisterUser(ctx context.Context, user *service_models.User) error {
 	hashPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
 	user.Password = string(hashPassword)
+	user.Roles = config.AppConfig.RBAC.DefaultRoles
 	return a.userRepo.CreateUser(ctx, user)
 }
 
-func (a *authService) LoginUser(ctx context.Context, username, password string) (string, error) {
+// LoginUser checks the password. Accounts with two-factor authentication get
+// a short-lived challenge instead of tokens, to be completed with
+// CompleteMFALogin.
+//
+// Failures are counted per username and per IP address. Unknown usernames and
+// wrong passwords both fail with ErrInvalidCredentials, and too many failures
+// with ErrLoginLocked, so the errors do not tell which accounts exist. An
+// account that reaches the limit is locked with exponential backoff and its
+// owner is emailed an unlock link, translated to locale when possible.
+func (a *authService) LoginUser(ctx context.Context, username, password, ip, locale string) (*service_models.LoginResult, error) {
+	lockout := config.AppConfig.Lockout
+	ipFailures, err := a.loginRepo.CountIPLoginFailures(ctx, ip, lockout.Window)
+	if err != nil {
+		return nil, err
+	}
+	if ipFailures >= lockout.IPMaxAttempts {
+		return nil, repository.ErrLoginLocked
+	}
+
 	user, err := a.userRepo.GetUserByUsername(ctx, username)
 	if err != nil {
-		return "", err
+		if !errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, err
+		}
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, a.loginFailed(ctx, nil, username, ip, locale)
+	}
+
+	var passwordErr error
+	if user.Password == "" {
+		// Users created through single sign-on have no password.
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		passwordErr = repository.ErrInvalidCredentials
+	} else {
+		passwordErr = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	}
+
+	lockedUntil, err := a.loginRepo.GetLoginLock(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if lockedUntil != nil && lockedUntil.After(time.Now()) {
+		return nil, repository.ErrLoginLocked
+	}
+
+	if passwordErr != nil {
+		return nil, a.loginFailed(ctx, user, username, ip, locale)
+	}
+	if err = a.loginRepo.ClearLoginFailures(ctx, user.ID, user.Username); err != nil {
+		return nil, err
+	}
+	return a.LoginAuthenticatedUser(ctx, user)
+}
+
+// LoginAuthenticatedUser finishes the login of a user whose identity was
+// already checked, by password or by an identity provider. Accounts with
+// two-factor authentication get a challenge instead of tokens.
+func (a *authService) LoginAuthenticatedUser(ctx context.Context, user *service_models.User) (*service_models.LoginResult, error) {
+	if user.MFAEnabled {
+		expiresAt := time.Now().Add(config.AppConfig.MFA.ChallengeTTL)
+		challenge, err := utils.GenerateMFAChallenge(user.ID, expiresAt)
+		if err != nil {
+			return nil, err
+		}
+		return &service_models.LoginResult{
+			MFARequired:  true,
+			MFAToken:     challenge,
+			MFAExpiresAt: &expiresAt,
+		}, nil
+	}
+
+	tokens, err := a.startSession(ctx, user, false)
+	if err != nil {
+		return nil, err
+	}
+	return &service_models.LoginResult{TokenPair: tokens}, nil
+}
+
+// CompleteMFALogin exchanges an MFA challenge and a TOTP or recovery code for
+// a token pair.
+func (a *authService) CompleteMFALogin(ctx context.Context, mfaToken, code string) (*service_models.TokenPair, error) {
+	claims, err := utils.ValidateMFAChallenge(mfaToken)
+	if err != nil {
+		return nil, repository.ErrInvalidMFAToken
 	}
-	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", err
+
+	user, err := a.userRepo.GetUserById(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, repository.ErrInvalidMFAToken
+		}
+		return nil, err
 	}
 
-	return utils.GenerateToken(user.Username, user.ID, user.IsAdmin)
+	if err = a.mfa.VerifyCode(ctx, user.ID, code); err != nil {
+		return nil, err
+	}
+	return a.startSession(ctx, user, true)
+}
+
+// loginFailed records a failed login and returns the error to report. user is
+// nil when the username does not exist; such usernames are refused once they
+// reach the limit too but have no account to lock.
+func (a *authService) loginFailed(ctx context.Context, user *service_models.User, username, ip, locale string) error {
+	lockout := config.AppConfig.Lockout
+	failures, err := a.loginRepo.RecordLoginFailure(ctx, username, ip, lockout.Window)
+	if err != nil {
+		return err
+	}
+
+	if failures.ByIP == lockout.IPMaxAttempts {
+		recordSecurityEvent(ctx, a.securityEventRepo, &service_models.SecurityEvent{
+			Event:     service_models.SecurityEventLoginThrottled,
+			Username:  username,
+			IPAddress: ip,
+			Details:   map[string]any{"failures": failures.ByIP, "window": lockout.Window.String()},
+		})
+	}
+
+	if failures.ByUsername < lockout.MaxAttempts {
+		return repository.ErrInvalidCredentials
+	}
+	if user == nil {
+		return repository.ErrLoginLocked
+	}
+
+	lockedUntil, err := a.loginRepo.LockAccount(ctx, user.ID, user.Username, lockout.LockoutBase, lockout.LockoutMax)
+	if err != nil {
+		return err
+	}
+
+	recordSecurityEvent(ctx, a.securityEventRepo, &service_models.SecurityEvent{
+		UserID:    &user.ID,
+		Event:     service_models.SecurityEventLoginLocked,
+		Username:  user.Username,
+		IPAddress: ip,
+		Details:   map[string]any{"failures": failures.ByUsername, "locked_until": lockedUntil.UTC().Format(time.RFC3339)},
+	})
+
+	if err = a.sendUnlockEmail(ctx, user, lockedUntil, locale); err != nil {
+		logger.Logger.Error("unable to send account unlock email", "user_id", user.ID, "error", err.Error())
+	}
+	return repository.ErrLoginLocked
+}
+
+func (a *authService) sendUnlockEmail(ctx context.Context, user *service_models.User, lockedUntil time.Time, locale string) error {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	ttl := config.AppConfig.Lockout.UnlockTokenTTL
+	if err = a.loginRepo.CreateAccountUnlock(ctx, user.ID, utils.HashToken(token), time.Now().Add(ttl)); err != nil {
+		return err
+	}
+
+	msg, err := mailer.NewMessage(user.Email, "account_locked.tmpl", locale, map[string]any{
+		"Username":    user.Username,
+		"Link":        fmt.Sprintf("%s?token=%s", config.AppConfig.Lockout.UnlockURL, url.QueryEscape(token)),
+		"LockedUntil": lockedUntil.UTC().Format("2006-01-02 15:04 MST"),
+		"TTL":         ttl,
+	})
+	if err != nil {
+		return err
+	}
+	return a.mailer.Send(ctx, msg)
+}
+
+// UnlockAccount lifts a login lockout with the token from the lockout email.
+func (a *authService) UnlockAccount(ctx context.Context, token string) error {
+	userID, err := a.loginRepo.UnlockAccount(ctx, utils.HashToken(token))
+	if err != nil {
+		return err
+	}
+
+	recordSecurityEvent(ctx, a.securityEventRepo, &service_models.SecurityEvent{
+		UserID: &userID,
+		Event:  service_models.SecurityEventAccountUnlocked,
+	})
+	return nil
+}
+
+// SetPassword replaces a user's password without asking for the current one,
+// for administrators. Like a reset, it ends every session of the user and
+// lifts any login lockout.
+func (a *authService) SetPassword(ctx context.Context, userID int64, password string) error {
+	user, err := a.userRepo.GetUserById(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err = a.userRepo.UpdateUserPassword(ctx, &service_models.User{ID: user.ID, Password: string(hashPassword)}); err != nil {
+		return err
+	}
+	if err = a.loginRepo.ClearLoginFailures(ctx, user.ID, user.Username); err != nil {
+		return err
+	}
+
+	recordSecurityEvent(ctx, a.securityEventRepo, &service_models.SecurityEvent{
+		UserID:   &user.ID,
+		Event:    service_models.SecurityEventPasswordSet,
+		Username: user.Username,
+	})
+	return nil
+}
+
+// startSession creates a new refresh token family and its first token pair.
+func (a *authService) startSession(ctx context.Context, user *service_models.User, mfa bool) (*service_models.TokenPair, error) {
+	family, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	stored := &service_models.RefreshToken{
+		UserID:    user.ID,
+		Family:    family,
+		MFA:       mfa,
+		ExpiresAt: time.Now().Add(config.AppConfig.JWT.RefreshTokenTTL),
+	}
+	if err = a.refreshTokenRepo.CreateRefreshToken(ctx, stored, hash); err != nil {
+		return nil, err
+	}
+
+	return newTokenPair(user, refreshToken, mfa)
+}
+
+// RefreshToken exchanges a refresh token for a new access token and a new
+// refresh token. Presenting a token that was already rotated or revoked means
+// it has leaked, so the whole family is revoked.
+func (a *authService) RefreshToken(ctx context.Context, refreshToken string) (*service_models.TokenPair, error) {
+	stored, err := a.refreshTokenRepo.GetRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, repository.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if stored.UsedAt != nil || stored.RevokedAt != nil {
+		return nil, a.revokeFamily(ctx, stored.Family)
+	}
+
+	if !stored.ExpiresAt.After(time.Now()) {
+		return nil, repository.ErrInvalidRefreshToken
+	}
+
+	user, err := a.userRepo.GetUserById(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, repository.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	newToken, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	rotated, err := a.refreshTokenRepo.RotateRefreshToken(ctx, stored.ID, hash, time.Now().Add(config.AppConfig.JWT.RefreshTokenTTL))
+	if err != nil {
+		if errors.Is(err, repository.ErrEditConflict) {
+			return nil, a.revokeFamily(ctx, stored.Family)
+		}
+		return nil, err
+	}
+
+	return newTokenPair(user, newToken, rotated.MFA)
+}
+
+func (a *authService) Logout(ctx context.Context, refreshToken string, allDevices bool) error {
+	stored, err := a.refreshTokenRepo.GetRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return repository.ErrInvalidRefreshToken
+		}
+		return err
+	}
+
+	if allDevices {
+		return a.refreshTokenRepo.RevokeUserTokens(ctx, stored.UserID)
+	}
+	return a.refreshTokenRepo.RevokeFamily(ctx, stored.Family)
+}
+
+// ValidateAccessToken checks the token signature and expiry and rejects tokens
+// of deleted users and tokens issued before the user's tokens_valid_after.
+// Permissions are read from the user's current roles, so role changes apply
+// to existing tokens immediately.
+func (a *authService) ValidateAccessToken(ctx context.Context, token string) (*service_models.Principal, error) {
+	claims, err := utils.ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := a.userRepo.GetAuthState(ctx, claims.UserID, claims.MFA)
+	if err != nil {
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil, repository.ErrTokenRevoked
+		}
+		return nil, err
+	}
+
+	if state.TokensValidAfter != nil && claims.IssuedAt.Time.Before(*state.TokensValidAfter) {
+		return nil, repository.ErrTokenRevoked
+	}
+
+	return &service_models.Principal{
+		UserID:      claims.UserID,
+		Username:    claims.Username,
+		MFA:         claims.MFA,
+		Permissions: state.Permissions,
+	}, nil
+}
+
+func (a *authService) revokeFamily(ctx context.Context, family string) error {
+	if err := a.refreshTokenRepo.RevokeFamily(ctx, family); err != nil {
+		return err
+	}
+	return repository.ErrInvalidRefreshToken
+}
+
+func newRefreshToken() (string, []byte, error) {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, utils.HashToken(token), nil
+}
+
+func newTokenPair(user *service_models.User, refreshToken string, mfa bool) (*service_models.TokenPair, error) {
+	expiresAt := time.Now().Add(config.AppConfig.JWT.AccessTokenTTL)
+	accessToken, err := utils.GenerateToken(user.Username, user.ID, mfa)
+	if err != nil {
+		return nil, err
+	}
+	return &service_models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
 }
 
 func (a *authService) GetWithTXT(tx *sql.Tx) Authenticate {
 	return &authService{
-		userRepo: a.userRepo.GetWithTXT(tx),
+		userRepo:          a.userRepo.GetWithTXT(tx),
+		refreshTokenRepo:  a.refreshTokenRepo.GetWithTXT(tx),
+		passwordResetRepo: a.passwordResetRepo.GetWithTXT(tx),
+		loginRepo:         a.loginRepo.GetWithTXT(tx),
+		securityEventRepo: a.securityEventRepo.GetWithTXT(tx),
+		mfa:               a.mfa.GetWithTXT(tx),
+		mailer:            a.mailer,
 	}
 }
 
-func (a *authService) ForgotPassword(ctx context.Context, username string) (string, error) {
+// ForgotPassword emails a single-use reset link to the user, translated to
+// locale when possible. Unknown usernames are ignored without an error so
+// callers cannot tell which accounts exist.
+func (a *authService) ForgotPassword(ctx context.Context, username, locale string) error {
 	user, err := a.userRepo.GetUserByUsername(ctx, username)
 	if err != nil {
-		return "", err
+		if errors.Is(err, repository.ErrRecordNotFound) {
+			return nil
+		}
+		return err
 	}
-	generatedPassword := utils.GeneratePassword(6)
-	hashPassword, err := bcrypt.GenerateFromPassword([]byte(generatedPassword), bcrypt.DefaultCost)
+
+	token, err := utils.GenerateRandomToken(32)
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	ttl := config.AppConfig.Reset.TokenTTL
+	if err = a.passwordResetRepo.CreatePasswordReset(ctx, user.ID, utils.HashToken(token), time.Now().Add(ttl)); err != nil {
+		return err
 	}
-	user.Password = string(hashPassword)
 
-	if err = a.userRepo.UpdateUserPassword(ctx, user); err != nil {
-		return "", err
+	msg, err := mailer.NewMessage(user.Email, "password_reset.tmpl", locale, map[string]any{
+		"Username": user.Username,
+		"Link":     fmt.Sprintf("%s?token=%s", config.AppConfig.Reset.URL, url.QueryEscape(token)),
+		"TTL":      ttl,
+	})
+	if err != nil {
+		return err
 	}
-	return generatedPassword, nil
+	return a.mailer.Send(ctx, msg)
+}
+
+func (a *authService) ResetPassword(ctx context.Context, token, password string) error {
+	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.passwordResetRepo.ResetPassword(ctx, utils.HashToken(token), string(hashPassword))
+	return err
 }
 
-func NewAuthenticateService(userRepo repository.User) Authenticate {
+func NewAuthenticateService(userRepo repository.User, refreshTokenRepo repository.RefreshToken, passwordResetRepo repository.PasswordReset, loginRepo repository.Login, securityEventRepo repository.SecurityEvent, mfa MFA, mail mailer.Mailer) Authenticate {
 	return &authService{
-		userRepo: userRepo,
+		userRepo:          userRepo,
+		refreshTokenRepo:  refreshTokenRepo,
+		passwordResetRepo: passwordResetRepo,
+		loginRepo:         loginRepo,
+		securityEventRepo: securityEventRepo,
+		mfa:               mfa,
+		mailer:            mail,
 	}
 }
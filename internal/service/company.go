@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type Company interface {
+	CreateCompany(ctx context.Context, company *service_models.Company, userID int64) (*service_models.Company, error)
+	GetCompany(ctx context.Context, idOrSlug string) (*service_models.Company, error)
+	GetAllCompanies(ctx context.Context, filter *service_models.CompanyFilter) ([]*service_models.Company, *service_models.Metadata, error)
+	UpdateCompany(ctx context.Context, company *service_models.Company, userID int64, canManageAll bool) (*service_models.Company, error)
+	DeleteCompany(ctx context.Context, id int64, userID int64, canManageAll bool) error
+	GetCompanyMembers(ctx context.Context, id int64, userID int64, canManageAll bool) ([]*service_models.CompanyMember, error)
+	SetMember(ctx context.Context, id int64, memberID int64, role service_models.CompanyRole, userID int64, canManageAll bool) (*service_models.CompanyMember, error)
+	RemoveMember(ctx context.Context, id int64, memberID int64, userID int64, canManageAll bool) error
+	GetWithTXT(tx *sql.Tx) Company
+}
+
+var nonSlugCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify lowercases name and joins its words with hyphens. Slugs without a
+// letter are rejected because companies are looked up by either ID or slug.
+func slugify(name string) (string, error) {
+	slug := strings.Trim(nonSlugCharacters.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if !strings.ContainsFunc(slug, unicode.IsLetter) {
+		return "", repository.ErrInvalidCompanySlug
+	}
+	return slug, nil
+}
+
+// canManageJob reports whether the user may edit a job and handle its
+// applications: users with canManageAll, the user who posted it and every
+// member of its company.
+func canManageJob(ctx context.Context, companyRepo repository.Company, job *service_models.Job, userID int64, canManageAll bool) error {
+	if canManageAll || job.UserID == userID {
+		return nil
+	}
+	if job.CompanyID != nil {
+		_, err := companyRepo.GetMemberRole(ctx, *job.CompanyID, userID)
+		switch {
+		case err == nil:
+			return nil
+		case !errors.Is(err, repository.ErrRecordNotFound):
+			return err
+		}
+	}
+	return repository.ErrUnAuthorized
+}
+
+type companyService struct {
+	companyRepo repository.Company
+}
+
+func (c *companyService) CreateCompany(ctx context.Context, company *service_models.Company, userID int64) (*service_models.Company, error) {
+	if err := c.setSlug(company); err != nil {
+		return nil, err
+	}
+	return c.companyRepo.CreateCompany(ctx, company, userID)
+}
+
+func (c *companyService) GetCompany(ctx context.Context, idOrSlug string) (*service_models.Company, error) {
+	if id, err := strconv.ParseInt(idOrSlug, 10, 64); err == nil {
+		return c.companyRepo.GetCompanyById(ctx, id)
+	}
+	return c.companyRepo.GetCompanyBySlug(ctx, strings.ToLower(idOrSlug))
+}
+
+func (c *companyService) GetAllCompanies(ctx context.Context, filter *service_models.CompanyFilter) ([]*service_models.Company, *service_models.Metadata, error) {
+	return c.companyRepo.GetAllCompanies(ctx, filter)
+}
+
+func (c *companyService) UpdateCompany(ctx context.Context, company *service_models.Company, userID int64, canManageAll bool) (*service_models.Company, error) {
+	existingCompany, err := c.requireOwner(ctx, company.ID, userID, canManageAll)
+	if err != nil {
+		return nil, err
+	}
+	if company.Slug == "" {
+		company.Slug = existingCompany.Slug
+	}
+	if err = c.setSlug(company); err != nil {
+		return nil, err
+	}
+	return c.companyRepo.UpdateCompany(ctx, company)
+}
+
+func (c *companyService) DeleteCompany(ctx context.Context, id int64, userID int64, canManageAll bool) error {
+	if _, err := c.requireOwner(ctx, id, userID, canManageAll); err != nil {
+		return err
+	}
+	return c.companyRepo.DeleteCompany(ctx, id)
+}
+
+func (c *companyService) GetCompanyMembers(ctx context.Context, id int64, userID int64, canManageAll bool) ([]*service_models.CompanyMember, error) {
+	if _, err := c.companyRepo.GetCompanyById(ctx, id); err != nil {
+		return nil, err
+	}
+	if !canManageAll {
+		if _, err := c.companyRepo.GetMemberRole(ctx, id, userID); err != nil {
+			if errors.Is(err, repository.ErrRecordNotFound) {
+				return nil, repository.ErrUnAuthorized
+			}
+			return nil, err
+		}
+	}
+	return c.companyRepo.GetCompanyMembers(ctx, id)
+}
+
+func (c *companyService) SetMember(ctx context.Context, id int64, memberID int64, role service_models.CompanyRole, userID int64, canManageAll bool) (*service_models.CompanyMember, error) {
+	if _, err := c.requireOwner(ctx, id, userID, canManageAll); err != nil {
+		return nil, err
+	}
+	if role != service_models.CompanyOwner {
+		if err := c.keepOwner(ctx, id, memberID); err != nil {
+			return nil, err
+		}
+	}
+	return c.companyRepo.SetMember(ctx, id, memberID, role)
+}
+
+// RemoveMember removes a member from a company. Owners and users who can
+// manage every company can remove anyone, and every member can leave on their
+// own.
+func (c *companyService) RemoveMember(ctx context.Context, id int64, memberID int64, userID int64, canManageAll bool) error {
+	if memberID != userID {
+		if _, err := c.requireOwner(ctx, id, userID, canManageAll); err != nil {
+			return err
+		}
+	}
+	if err := c.keepOwner(ctx, id, memberID); err != nil {
+		return err
+	}
+	return c.companyRepo.RemoveMember(ctx, id, memberID)
+}
+
+// requireOwner loads the company and checks that the user owns it or can
+// manage every company.
+func (c *companyService) requireOwner(ctx context.Context, id int64, userID int64, canManageAll bool) (*service_models.Company, error) {
+	company, err := c.companyRepo.GetCompanyById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if canManageAll {
+		return company, nil
+	}
+	role, err := c.companyRepo.GetMemberRole(ctx, id, userID)
+	switch {
+	case errors.Is(err, repository.ErrRecordNotFound):
+		return nil, repository.ErrUnAuthorized
+	case err != nil:
+		return nil, err
+	case role != service_models.CompanyOwner:
+		return nil, repository.ErrUnAuthorized
+	}
+	return company, nil
+}
+
+// keepOwner returns ErrLastCompanyOwner when memberID is the only owner left.
+func (c *companyService) keepOwner(ctx context.Context, id int64, memberID int64) error {
+	role, err := c.companyRepo.GetMemberRole(ctx, id, memberID)
+	switch {
+	case errors.Is(err, repository.ErrRecordNotFound):
+		return nil
+	case err != nil:
+		return err
+	case role != service_models.CompanyOwner:
+		return nil
+	}
+
+	owners, err := c.companyRepo.CountOwners(ctx, id)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return repository.ErrLastCompanyOwner
+	}
+	return nil
+}
+
+func (c *companyService) setSlug(company *service_models.Company) error {
+	source := company.Slug
+	if source == "" {
+		source = company.Name
+	}
+	slug, err := slugify(source)
+	if err != nil {
+		return err
+	}
+	company.Slug = slug
+	return nil
+}
+
+func (c *companyService) GetWithTXT(tx *sql.Tx) Company {
+	return &companyService{
+		companyRepo: c.companyRepo.GetWithTXT(tx),
+	}
+}
+
+func NewCompanyService(companyRepo repository.Company) Company {
+	return &companyService{
+		companyRepo: companyRepo,
+	}
+}
@@ -3,73 +3,253 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"strings"
+	"time"
 )
 
 type Job interface {
-	CreateJob(ctx context.Context, job *service_models.Job) (*service_models.Job, error)
-	GetAllJobs(ctx context.Context) ([]*service_models.Job, error)
-	GetAllJobsByUserID(ctx context.Context, userID int64) ([]*service_models.Job, error)
-	GetJobById(ctx context.Context, id int64) (*service_models.Job, error)
-	UpdateJob(ctx context.Context, job *service_models.Job, userID int64, isAdmin bool) (*service_models.Job, error)
-	DeleteJob(ctx context.Context, id int64, userId int64, isAdmin bool) error
+	CreateJob(ctx context.Context, job *service_models.Job, canManageAll bool) (*service_models.Job, error)
+	GetAllJobs(ctx context.Context, filter *service_models.JobFilter) ([]*service_models.Job, *service_models.Metadata, error)
+	SearchJobs(ctx context.Context, filter *service_models.JobSearchFilter) ([]*service_models.JobSearchResult, *service_models.Metadata, error)
+	GetJobById(ctx context.Context, id int64, userID int64, canManageAll bool) (*service_models.Job, error)
+	UpdateJob(ctx context.Context, job *service_models.Job, userID int64, canManageAll bool) (*service_models.Job, error)
+	PublishJob(ctx context.Context, id int64, userID int64, canManageAll bool) (*service_models.Job, error)
+	CloseJob(ctx context.Context, id int64, userID int64, canManageAll bool) (*service_models.Job, error)
+	DeleteJob(ctx context.Context, id int64, userId int64, canManageAll bool) error
+	SweepJobs(ctx context.Context, retention time.Duration) (*service_models.JobSweepResult, error)
 	GetWithTXT(tx *sql.Tx) Job
 }
 
 type jobService struct {
-	jobRepo repository.Job
+	jobRepo     repository.Job
+	companyRepo repository.Company
+	tagRepo     repository.Tag
+	transactor  Transactor
 }
 
-func (j *jobService) CreateJob(ctx context.Context, job *service_models.Job) (*service_models.Job, error) {
+func (j *jobService) CreateJob(ctx context.Context, job *service_models.Job, canManageAll bool) (*service_models.Job, error) {
+	if job.ExpiresAt != nil && !job.ExpiresAt.After(time.Now()) {
+		return nil, repository.ErrInvalidExpiry
+	}
+	if err := validateSalary(job); err != nil {
+		return nil, err
+	}
+	if err := j.setJobCompany(ctx, job, nil, job.UserID, canManageAll); err != nil {
+		return nil, err
+	}
+	if err := j.checkTags(ctx, job); err != nil {
+		return nil, err
+	}
+	job.Status = service_models.JobDraft
 	return j.jobRepo.CreateJob(ctx, job)
 }
 
-func (j *jobService) GetAllJobs(ctx context.Context) ([]*service_models.Job, error) {
-	return j.jobRepo.GetAllJobs(ctx)
+func (j *jobService) GetAllJobs(ctx context.Context, filter *service_models.JobFilter) ([]*service_models.Job, *service_models.Metadata, error) {
+	return j.jobRepo.GetAllJobs(ctx, filter)
 }
 
-func (j *jobService) GetAllJobsByUserID(ctx context.Context, userID int64) ([]*service_models.Job, error) {
-	return j.jobRepo.GetAllJobsByUserID(ctx, userID)
+func (j *jobService) SearchJobs(ctx context.Context, filter *service_models.JobSearchFilter) ([]*service_models.JobSearchResult, *service_models.Metadata, error) {
+	return j.jobRepo.SearchJobs(ctx, filter)
 }
 
-func (j *jobService) GetJobById(ctx context.Context, id int64) (*service_models.Job, error) {
-	return j.jobRepo.GetJobById(ctx, id)
+func (j *jobService) GetJobById(ctx context.Context, id int64, userID int64, canManageAll bool) (*service_models.Job, error) {
+	job, err := j.jobRepo.GetJobById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !job.IsOpen(time.Now()) {
+		if err = canManageJob(ctx, j.companyRepo, job, userID, canManageAll); err != nil {
+			if errors.Is(err, repository.ErrUnAuthorized) {
+				return nil, repository.ErrRecordNotFound
+			}
+			return nil, err
+		}
+	}
+	return job, nil
 }
 
-func (j *jobService) UpdateJob(ctx context.Context, job *service_models.Job, userID int64, isAdmin bool) (*service_models.Job, error) {
+func (j *jobService) UpdateJob(ctx context.Context, job *service_models.Job, userID int64, canManageAll bool) (*service_models.Job, error) {
 	exisingJob, err := j.jobRepo.GetJobById(ctx, job.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	if !isAdmin && exisingJob.UserID != userID {
-		return nil, repository.ErrUnAuthorized
+	if err = canManageJob(ctx, j.companyRepo, exisingJob, userID, canManageAll); err != nil {
+		return nil, err
+	}
+
+	if job.ExpiresAt != nil && !job.ExpiresAt.After(time.Now()) {
+		return nil, repository.ErrInvalidExpiry
+	}
+
+	if err = validateSalary(job); err != nil {
+		return nil, err
+	}
+
+	if job.CompanyID == nil {
+		job.CompanyID = exisingJob.CompanyID
+	}
+	if err = j.setJobCompany(ctx, job, exisingJob.CompanyID, userID, canManageAll); err != nil {
+		return nil, err
+	}
+
+	if job.Tags == nil {
+		job.Tags = exisingJob.Tags
+	}
+	if err = j.checkTags(ctx, job); err != nil {
+		return nil, err
+	}
+
+	if job.ExpiresAt == nil && !job.ClearExpiry {
+		job.ExpiresAt = exisingJob.ExpiresAt
 	}
 	return j.jobRepo.UpdateJob(ctx, job)
 }
 
-func (j *jobService) DeleteJob(ctx context.Context, id int64, userID int64, isAdmin bool) error {
+func (j *jobService) PublishJob(ctx context.Context, id int64, userID int64, canManageAll bool) (*service_models.Job, error) {
+	job, err := j.jobRepo.GetJobById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = canManageJob(ctx, j.companyRepo, job, userID, canManageAll); err != nil {
+		return nil, err
+	}
+
+	if job.Status == service_models.JobPublished || job.Status == service_models.JobArchived {
+		return nil, repository.ErrInvalidJobStatus
+	}
+
+	if job.ExpiresAt != nil && !job.ExpiresAt.After(time.Now()) {
+		return nil, repository.ErrInvalidExpiry
+	}
+
+	return j.jobRepo.UpdateJobStatus(ctx, id, job.Status, service_models.JobPublished)
+}
+
+func (j *jobService) CloseJob(ctx context.Context, id int64, userID int64, canManageAll bool) (*service_models.Job, error) {
+	job, err := j.jobRepo.GetJobById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = canManageJob(ctx, j.companyRepo, job, userID, canManageAll); err != nil {
+		return nil, err
+	}
+
+	if job.Status != service_models.JobPublished {
+		return nil, repository.ErrInvalidJobStatus
+	}
+
+	return j.jobRepo.UpdateJobStatus(ctx, id, job.Status, service_models.JobClosed)
+}
+
+func (j *jobService) DeleteJob(ctx context.Context, id int64, userID int64, canManageAll bool) error {
 	existingJob, err := j.jobRepo.GetJobById(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	if !isAdmin && existingJob.UserID != userID {
-		return repository.ErrUnAuthorized
+	if err = canManageJob(ctx, j.companyRepo, existingJob, userID, canManageAll); err != nil {
+		return err
 	}
 
 	return j.jobRepo.DeleteJob(ctx, id)
 }
 
+// SweepJobs expires published jobs past their expires_at and archives closed
+// and expired jobs that stopped accepting applications more than retention ago.
+func (j *jobService) SweepJobs(ctx context.Context, retention time.Duration) (*service_models.JobSweepResult, error) {
+	var result *service_models.JobSweepResult
+	err := j.transactor.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		result, err = j.jobRepo.GetWithTXT(tx).SweepJobs(ctx, time.Now().Add(-retention))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// setJobCompany copies the company name onto a job that references a company.
+// Only members of a company can move a job to it; currentCompanyID is the
+// company the job already belongs to, if any.
+func (j *jobService) setJobCompany(ctx context.Context, job *service_models.Job, currentCompanyID *int64, userID int64, canManageAll bool) error {
+	if job.CompanyID == nil {
+		return nil
+	}
+
+	company, err := j.companyRepo.GetCompanyById(ctx, *job.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	if !canManageAll && (currentCompanyID == nil || *currentCompanyID != company.ID) {
+		if _, err = j.companyRepo.GetMemberRole(ctx, company.ID, userID); err != nil {
+			if errors.Is(err, repository.ErrRecordNotFound) {
+				return repository.ErrUnAuthorized
+			}
+			return err
+		}
+	}
+
+	job.Company = company.Name
+	return nil
+}
+
+// checkTags normalizes the job's tags and rejects names that are not in the
+// tag taxonomy.
+func (j *jobService) checkTags(ctx context.Context, job *service_models.Job) error {
+	job.Tags = service_models.NormalizeTags(job.Tags)
+	if len(job.Tags) == 0 {
+		return nil
+	}
+
+	missing, err := j.tagRepo.GetMissingTags(ctx, job.Tags)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", repository.ErrUnknownTag, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// validateSalary checks that a structured salary names its currency and pay
+// period and that the range is not inverted.
+func validateSalary(job *service_models.Job) error {
+	if job.SalaryMin == nil && job.SalaryMax == nil {
+		return nil
+	}
+	if job.SalaryCurrency == nil || job.SalaryPeriod == nil {
+		return fmt.Errorf("%w: salary_currency and salary_period are required with a salary amount", repository.ErrInvalidSalary)
+	}
+	if job.SalaryMin != nil && job.SalaryMax != nil && *job.SalaryMin > *job.SalaryMax {
+		return fmt.Errorf("%w: salary_min must not exceed salary_max", repository.ErrInvalidSalary)
+	}
+	return nil
+}
+
 func (j *jobService) GetWithTXT(tx *sql.Tx) Job {
 	return &jobService{
-		jobRepo: j.jobRepo.GetWithTXT(tx),
+		jobRepo:     j.jobRepo.GetWithTXT(tx),
+		companyRepo: j.companyRepo.GetWithTXT(tx),
+		tagRepo:     j.tagRepo.GetWithTXT(tx),
+		transactor:  j.transactor,
 	}
 }
 
-func NewJobService(jobRepo repository.Job) Job {
+func NewJobService(jobRepo repository.Job, companyRepo repository.Company, tagRepo repository.Tag, transactor Transactor) Job {
 	return &jobService{
-		jobRepo: jobRepo,
+		jobRepo:     jobRepo,
+		companyRepo: companyRepo,
+		tagRepo:     tagRepo,
+		transactor:  transactor,
 	}
 }
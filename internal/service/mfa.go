@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"github.com/saleh-ghazimoradi/GoJobs/utils"
+	"strings"
+	"time"
+)
+
+const recoveryCodeCount = 10
+
+type MFA interface {
+	BeginTOTPEnrollment(ctx context.Context, userID int64) (*service_models.TOTPEnrollment, error)
+	ConfirmTOTPEnrollment(ctx context.Context, userID int64, code string) (*service_models.RecoveryCodes, error)
+	DisableTOTP(ctx context.Context, userID int64, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) (*service_models.RecoveryCodes, error)
+	GetMFAStatus(ctx context.Context, userID int64) (*service_models.MFAStatus, error)
+	VerifyCode(ctx context.Context, userID int64, code string) error
+	GetMFAPolicy(ctx context.Context) (*service_models.MFAPolicy, error)
+	UpdateMFAPolicy(ctx context.Context, requireAdminMFA bool) (*service_models.MFAPolicy, error)
+	GetWithTXT(tx *sql.Tx) MFA
+}
+
+type mfaService struct {
+	mfaRepo  repository.MFA
+	userRepo repository.User
+}
+
+// BeginTOTPEnrollment generates a new secret for the user. It only takes
+// effect once ConfirmTOTPEnrollment verifies a first code.
+func (m *mfaService) BeginTOTPEnrollment(ctx context.Context, userID int64) (*service_models.TOTPEnrollment, error) {
+	user, err := m.userRepo.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, repository.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := utils.Encrypt(mfaEncryptionKey(), []byte(secret))
+	if err != nil {
+		return nil, err
+	}
+	if err = m.mfaRepo.SetPendingTOTP(ctx, userID, encrypted); err != nil {
+		return nil, err
+	}
+
+	return &service_models.TOTPEnrollment{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(config.AppConfig.MFA.Issuer, user.Username, secret),
+	}, nil
+}
+
+func (m *mfaService) ConfirmTOTPEnrollment(ctx context.Context, userID int64, code string) (*service_models.RecoveryCodes, error) {
+	totp, err := m.mfaRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if totp.EnabledAt != nil {
+		return nil, repository.ErrMFAAlreadyEnabled
+	}
+	if totp.Secret == nil {
+		return nil, repository.ErrMFANotStarted
+	}
+
+	step, err := m.checkTOTP(ctx, userID, totp, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err = m.mfaRepo.EnableTOTP(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return &service_models.RecoveryCodes{Codes: codes}, nil
+}
+
+// DisableTOTP turns two-factor authentication off after checking a current
+// code. Admins cannot turn it off while the policy requires it.
+func (m *mfaService) DisableTOTP(ctx context.Context, userID int64, code string) error {
+	user, err := m.userRepo.GetUserById(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.HasRole(service_models.RoleAdmin) {
+		policy, err := m.mfaRepo.GetMFAPolicy(ctx)
+		if err != nil {
+			return err
+		}
+		if policy.RequireAdminMFA {
+			return repository.ErrMFARequired
+		}
+	}
+
+	if err = m.VerifyCode(ctx, userID, code); err != nil {
+		return err
+	}
+	return m.mfaRepo.DisableTOTP(ctx, userID)
+}
+
+func (m *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) (*service_models.RecoveryCodes, error) {
+	if err := m.VerifyCode(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err = m.mfaRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return &service_models.RecoveryCodes{Codes: codes}, nil
+}
+
+func (m *mfaService) GetMFAStatus(ctx context.Context, userID int64) (*service_models.MFAStatus, error) {
+	user, err := m.userRepo.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	remaining, err := m.mfaRepo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := m.mfaRepo.GetMFAPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &service_models.MFAStatus{
+		TOTPEnabled:            user.MFAEnabled,
+		RecoveryCodesRemaining: remaining,
+		RequiredForAdmins:      policy.RequireAdminMFA,
+	}, nil
+}
+
+// VerifyCode checks a TOTP code or, failing that, consumes a recovery code.
+// Repeated failures lock the second factor for a while.
+func (m *mfaService) VerifyCode(ctx context.Context, userID int64, code string) error {
+	totp, err := m.mfaRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if totp.EnabledAt == nil {
+		return repository.ErrMFANotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) != 6 {
+		if err = m.checkLock(totp); err != nil {
+			return err
+		}
+		err = m.mfaRepo.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+		if errors.Is(err, repository.ErrInvalidMFACode) {
+			return m.recordFailure(ctx, userID)
+		}
+		return err
+	}
+
+	_, err = m.checkTOTP(ctx, userID, totp, code)
+	return err
+}
+
+func (m *mfaService) GetMFAPolicy(ctx context.Context) (*service_models.MFAPolicy, error) {
+	return m.mfaRepo.GetMFAPolicy(ctx)
+}
+
+func (m *mfaService) UpdateMFAPolicy(ctx context.Context, requireAdminMFA bool) (*service_models.MFAPolicy, error) {
+	return m.mfaRepo.UpdateMFAPolicy(ctx, requireAdminMFA)
+}
+
+// checkTOTP validates code against the stored secret and records the time
+// step it matched, which also rejects replays of the same code.
+func (m *mfaService) checkTOTP(ctx context.Context, userID int64, totp *service_models.TOTP, code string) (int64, error) {
+	if err := m.checkLock(totp); err != nil {
+		return 0, err
+	}
+
+	secret, err := utils.Decrypt(mfaEncryptionKey(), totp.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := utils.ValidateTOTP(string(secret), code, time.Now())
+	if !ok || (totp.LastStep != nil && step <= *totp.LastStep) {
+		return 0, m.recordFailure(ctx, userID)
+	}
+
+	// Enrollment stores the step together with the recovery codes.
+	if totp.EnabledAt == nil {
+		return step, nil
+	}
+	if err = m.mfaRepo.UseTOTPStep(ctx, userID, step); err != nil {
+		return 0, err
+	}
+	return step, nil
+}
+
+func (m *mfaService) checkLock(totp *service_models.TOTP) error {
+	if totp.LockedUntil != nil && totp.LockedUntil.After(time.Now()) {
+		return repository.ErrMFALocked
+	}
+	return nil
+}
+
+func (m *mfaService) recordFailure(ctx context.Context, userID int64) error {
+	cfg := config.AppConfig.MFA
+	if err := m.mfaRepo.RecordMFAFailure(ctx, userID, cfg.MaxAttempts, cfg.LockoutPeriod); err != nil {
+		return err
+	}
+	return repository.ErrInvalidMFACode
+}
+
+func (m *mfaService) GetWithTXT(tx *sql.Tx) MFA {
+	return &mfaService{
+		mfaRepo:  m.mfaRepo.GetWithTXT(tx),
+		userRepo: m.userRepo.GetWithTXT(tx),
+	}
+}
+
+func NewMFAService(mfaRepo repository.MFA, userRepo repository.User) MFA {
+	return &mfaService{
+		mfaRepo:  mfaRepo,
+		userRepo: userRepo,
+	}
+}
+
+// newRecoveryCodes returns codes formatted as xxxxx-xxxxx and their hashes.
+// The codes carry 50 bits of entropy each, so a plain SHA-256 is enough.
+func newRecoveryCodes() ([]string, [][]byte, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([][]byte, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case and dashes, so codes can be typed loosely.
+func hashRecoveryCode(code string) []byte {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return utils.HashToken(code)
+}
+
+func mfaEncryptionKey() string {
+	if key := config.AppConfig.MFA.EncryptionKey; key != "" {
+		return key
+	}
+	return config.AppConfig.JWT.SecretKEY
+}
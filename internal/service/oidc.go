@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"github.com/saleh-ghazimoradi/GoJobs/utils"
+	"golang.org/x/oauth2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+type OIDC interface {
+	BeginLogin(ctx context.Context) (authURL string, flow string, err error)
+	CompleteLogin(ctx context.Context, flow, state, code string) (*service_models.LoginResult, error)
+	GetWithTXT(tx *sql.Tx) OIDC
+}
+
+type oidcService struct {
+	identityRepo repository.Identity
+	userRepo     repository.User
+	auth         Authenticate
+
+	mu       *sync.Mutex
+	provider *oidc.Provider
+}
+
+// oidcHTTPClient bounds the requests made to the identity provider.
+var oidcHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// BeginLogin starts an authorization code flow with PKCE. It returns the
+// provider URL to redirect the browser to and the sealed flow state, which the
+// caller must hand back to CompleteLogin from the same browser.
+func (o *oidcService) BeginLogin(ctx context.Context) (string, string, error) {
+	oauthConfig, _, err := o.client(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	flow := service_models.OIDCFlow{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: oauth2.GenerateVerifier(),
+		ExpiresAt:    time.Now().Add(config.AppConfig.OIDC.StateTTL),
+	}
+	sealed, err := sealOIDCFlow(&flow)
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL := oauthConfig.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(flow.CodeVerifier))
+	return authURL, sealed, nil
+}
+
+// CompleteLogin exchanges the authorization code, verifies the ID token
+// against the provider's keys and logs in the user linked to it, linking or
+// creating one on the first sign-in when the config allows it.
+func (o *oidcService) CompleteLogin(ctx context.Context, sealed, state, code string) (*service_models.LoginResult, error) {
+	oauthConfig, verifier, err := o.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	flow, err := openOIDCFlow(sealed)
+	if err != nil || !flow.ExpiresAt.After(time.Now()) || subtle.ConstantTimeCompare([]byte(flow.State), []byte(state)) != 1 {
+		return nil, repository.ErrInvalidOIDCState
+	}
+
+	ctx = oidc.ClientContext(ctx, oidcHTTPClient)
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(flow.CodeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", repository.ErrOIDCLoginFailed, err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: no id_token in the token response", repository.ErrOIDCLoginFailed)
+	}
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", repository.ErrOIDCLoginFailed, err)
+	}
+
+	var claims service_models.OIDCClaims
+	if err = idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", repository.ErrOIDCLoginFailed, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(flow.Nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce mismatch", repository.ErrOIDCLoginFailed)
+	}
+
+	user, err := o.findOrCreateUser(ctx, idToken.Issuer, &claims)
+	if err != nil {
+		return nil, err
+	}
+	return o.auth.LoginAuthenticatedUser(ctx, user)
+}
+
+func (o *oidcService) findOrCreateUser(ctx context.Context, issuer string, claims *service_models.OIDCClaims) (*service_models.User, error) {
+	identity, err := o.identityRepo.GetIdentity(ctx, issuer, claims.Subject)
+	if err == nil {
+		if err = o.identityRepo.RecordIdentityLogin(ctx, identity.ID, claims.Email); err != nil {
+			return nil, err
+		}
+		return o.userRepo.GetUserById(ctx, identity.UserID)
+	}
+	if !errors.Is(err, repository.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	cfg := config.AppConfig.OIDC
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, repository.ErrOIDCEmailRequired
+	}
+
+	if cfg.LinkByEmail {
+		user, err := o.userRepo.GetUserByEmail(ctx, claims.Email)
+		switch {
+		case err == nil:
+			identity = &service_models.UserIdentity{UserID: user.ID, Issuer: issuer, Subject: claims.Subject, Email: claims.Email}
+			if err = o.identityRepo.CreateIdentity(ctx, identity); err != nil {
+				return nil, err
+			}
+			return user, nil
+		case !errors.Is(err, repository.ErrRecordNotFound):
+			return nil, err
+		}
+	}
+
+	if !cfg.AutoCreateUsers {
+		return nil, repository.ErrOIDCAccountNotLinked
+	}
+	return o.createUser(ctx, issuer, claims)
+}
+
+// createUser creates a user without a password for a new identity. The
+// username comes from the preferred_username claim or the email address and
+// gets a random suffix when it is taken.
+func (o *oidcService) createUser(ctx context.Context, issuer string, claims *service_models.OIDCClaims) (*service_models.User, error) {
+	base := oidcUsername(claims)
+	verifiedAt := time.Now()
+
+	for attempt := 0; ; attempt++ {
+		username := base
+		if attempt > 0 {
+			suffix, err := utils.GenerateRandomToken(3)
+			if err != nil {
+				return nil, err
+			}
+			username = base + "-" + strings.ToLower(suffix)
+		}
+
+		user := &service_models.User{
+			Username:        username,
+			Email:           claims.Email,
+			EmailVerifiedAt: &verifiedAt,
+			Roles:           config.AppConfig.RBAC.DefaultRoles,
+		}
+		identity := &service_models.UserIdentity{Issuer: issuer, Subject: claims.Subject}
+
+		err := o.identityRepo.CreateUserWithIdentity(ctx, user, identity)
+		switch {
+		case err == nil:
+			return user, nil
+		case errors.Is(err, repository.ErrDuplicateUsernames) && attempt < 5:
+			continue
+		default:
+			return nil, err
+		}
+	}
+}
+
+// client discovers the provider on first use, so the API starts even when the
+// provider is down, and retries discovery until it succeeds.
+func (o *oidcService) client(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	cfg := config.AppConfig.OIDC
+	if cfg.Issuer == "" || cfg.ClientID == "" {
+		return nil, nil, repository.ErrOIDCDisabled
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.provider == nil {
+		provider, err := oidc.NewProvider(oidc.ClientContext(ctx, oidcHTTPClient), cfg.Issuer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: discovery: %v", repository.ErrOIDCLoginFailed, err)
+		}
+		o.provider = provider
+	}
+
+	oauthConfig := &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Endpoint:     o.provider.Endpoint(),
+		Scopes:       cfg.Scopes,
+	}
+	return oauthConfig, o.provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}), nil
+}
+
+func oidcUsername(claims *service_models.OIDCClaims) string {
+	name := claims.PreferredUsername
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+		return -1
+	}, name)
+	if name == "" {
+		name = "user"
+	}
+	if len(name) > 90 {
+		name = name[:90]
+	}
+	return name
+}
+
+func sealOIDCFlow(flow *service_models.OIDCFlow) (string, error) {
+	plaintext, err := json.Marshal(flow)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := utils.Encrypt(oidcCookieKey(), plaintext)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func openOIDCFlow(sealed string) (*service_models.OIDCFlow, error) {
+	ciphertext, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := utils.Decrypt(oidcCookieKey(), ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	var flow service_models.OIDCFlow
+	if err = json.Unmarshal(plaintext, &flow); err != nil {
+		return nil, err
+	}
+	return &flow, nil
+}
+
+func oidcCookieKey() string {
+	if key := config.AppConfig.OIDC.CookieKey; key != "" {
+		return key
+	}
+	return config.AppConfig.JWT.SecretKEY
+}
+
+func (o *oidcService) GetWithTXT(tx *sql.Tx) OIDC {
+	return &oidcService{
+		identityRepo: o.identityRepo.GetWithTXT(tx),
+		userRepo:     o.userRepo.GetWithTXT(tx),
+		auth:         o.auth.GetWithTXT(tx),
+		mu:           o.mu,
+		provider:     o.provider,
+	}
+}
+
+func NewOIDCService(identityRepo repository.Identity, userRepo repository.User, auth Authenticate) OIDC {
+	return &oidcService{
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		auth:         auth,
+		mu:           &sync.Mutex{},
+	}
+}
@@ -0,0 +1,431 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+)
+
+const (
+	mockClientID     = "gojobs-test"
+	mockClientSecret = "gojobs-test-secret"
+	mockKeyID        = "mock-key"
+)
+
+// mockProvider is a minimal OIDC provider: discovery, JWKS and a token
+// endpoint that checks the PKCE verifier of each issued code.
+type mockProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockGrant
+}
+
+type mockGrant struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &mockProvider{key: key, codes: map[string]mockGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeMockJSON(w, http.StatusOK, map[string]any{
+			"issuer":                                p.server.URL,
+			"authorization_endpoint":                p.server.URL + "/authorize",
+			"token_endpoint":                        p.server.URL + "/token",
+			"jwks_uri":                              p.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeMockJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": mockKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", p.token)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != mockClientID || clientSecret != mockClientSecret {
+		writeMockJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	grant, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, grant.claims)
+	idToken.Header["kid"] = mockKeyID
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		writeMockJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeMockJSON(w, http.StatusOK, map[string]any{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+// authorize plays the part of the browser at the authorization endpoint: it
+// reads the request BeginLogin built and issues a code for it. The returned
+// claims can be changed before the code is redeemed.
+func (p *mockProvider) authorize(t *testing.T, authURL string, subject, email string) (state, code string, claims jwt.MapClaims) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	if u.Path != "/authorize" || query.Get("client_id") != mockClientID || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected authorization request: %s", authURL)
+	}
+
+	now := time.Now()
+	claims = jwt.MapClaims{
+		"iss":            p.server.URL,
+		"aud":            mockClientID,
+		"sub":            subject,
+		"email":          email,
+		"email_verified": true,
+		"nonce":          query.Get("nonce"),
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	}
+	code = "code-" + subject
+	p.mu.Lock()
+	p.codes[code] = mockGrant{challenge: query.Get("code_challenge"), claims: claims}
+	p.mu.Unlock()
+	return query.Get("state"), code, claims
+}
+
+func writeMockJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type fakeIdentityRepo struct {
+	repository.Identity
+	identities   []*service_models.UserIdentity
+	created      []*service_models.User
+	takenNames   map[string]bool
+	createCalled int
+}
+
+func (f *fakeIdentityRepo) GetIdentity(ctx context.Context, issuer, subject string) (*service_models.UserIdentity, error) {
+	for _, identity := range f.identities {
+		if identity.Issuer == issuer && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, repository.ErrRecordNotFound
+}
+
+func (f *fakeIdentityRepo) CreateIdentity(ctx context.Context, identity *service_models.UserIdentity) error {
+	f.identities = append(f.identities, identity)
+	return nil
+}
+
+func (f *fakeIdentityRepo) CreateUserWithIdentity(ctx context.Context, user *service_models.User, identity *service_models.UserIdentity) error {
+	f.createCalled++
+	if f.takenNames[user.Username] {
+		return repository.ErrDuplicateUsernames
+	}
+	user.ID = int64(100 + len(f.created))
+	identity.UserID = user.ID
+	f.created = append(f.created, user)
+	f.identities = append(f.identities, identity)
+	return nil
+}
+
+func (f *fakeIdentityRepo) RecordIdentityLogin(ctx context.Context, id int64, email string) error {
+	return nil
+}
+
+type fakeUserRepo struct {
+	repository.User
+	users []*service_models.User
+}
+
+func (f *fakeUserRepo) GetUserByEmail(ctx context.Context, email string) (*service_models.User, error) {
+	for _, user := range f.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, repository.ErrRecordNotFound
+}
+
+func (f *fakeUserRepo) GetUserById(ctx context.Context, id int64) (*service_models.User, error) {
+	for _, user := range f.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, repository.ErrRecordNotFound
+}
+
+type fakeAuth struct {
+	Authenticate
+	loggedIn []*service_models.User
+}
+
+func (f *fakeAuth) LoginAuthenticatedUser(ctx context.Context, user *service_models.User) (*service_models.LoginResult, error) {
+	f.loggedIn = append(f.loggedIn, user)
+	return &service_models.LoginResult{}, nil
+}
+
+type oidcTest struct {
+	provider   *mockProvider
+	identities *fakeIdentityRepo
+	users      *fakeUserRepo
+	auth       *fakeAuth
+	service    OIDC
+}
+
+func newOIDCTest(t *testing.T, linkByEmail bool) *oidcTest {
+	t.Helper()
+	provider := newMockProvider(t)
+
+	previous := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = previous })
+	config.AppConfig = &config.Config{
+		OIDC: config.OIDC{
+			Issuer:          provider.server.URL,
+			ClientID:        mockClientID,
+			ClientSecret:    mockClientSecret,
+			RedirectURL:     "http://localhost:8080/v1/oidc/callback",
+			Scopes:          []string{"openid", "email", "profile"},
+			StateTTL:        10 * time.Minute,
+			CookieKey:       "test-cookie-key",
+			AutoCreateUsers: true,
+			LinkByEmail:     linkByEmail,
+		},
+		RBAC: config.RBAC{DefaultRoles: []string{service_models.RoleCandidate}},
+	}
+
+	o := &oidcTest{
+		provider:   provider,
+		identities: &fakeIdentityRepo{takenNames: map[string]bool{}},
+		users:      &fakeUserRepo{},
+		auth:       &fakeAuth{},
+	}
+	o.service = NewOIDCService(o.identities, o.users, o.auth)
+	return o
+}
+
+func (o *oidcTest) begin(t *testing.T) (authURL, flow string) {
+	t.Helper()
+	authURL, flow, err := o.service.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	return authURL, flow
+}
+
+func TestOIDCCompleteLoginCreatesUser(t *testing.T) {
+	o := newOIDCTest(t, false)
+	authURL, flow := o.begin(t)
+	state, code, _ := o.provider.authorize(t, authURL, "subject-1", "alice@example.com")
+
+	if _, err := o.service.CompleteLogin(context.Background(), flow, state, code); err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if len(o.identities.created) != 1 || len(o.auth.loggedIn) != 1 {
+		t.Fatalf("created %d users and logged in %d, want 1 and 1", len(o.identities.created), len(o.auth.loggedIn))
+	}
+	user := o.auth.loggedIn[0]
+	if user.Username != "alice" || user.Email != "alice@example.com" || user.EmailVerifiedAt == nil {
+		t.Errorf("unexpected user %+v", user)
+	}
+	identity := o.identities.identities[0]
+	if identity.Issuer != o.provider.server.URL || identity.Subject != "subject-1" || identity.UserID != user.ID {
+		t.Errorf("unexpected identity %+v", identity)
+	}
+}
+
+func TestOIDCCompleteLoginRetriesTakenUsername(t *testing.T) {
+	o := newOIDCTest(t, false)
+	o.identities.takenNames["alice"] = true
+	authURL, flow := o.begin(t)
+	state, code, _ := o.provider.authorize(t, authURL, "subject-1", "alice@example.com")
+
+	if _, err := o.service.CompleteLogin(context.Background(), flow, state, code); err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if o.identities.createCalled != 2 {
+		t.Errorf("CreateUserWithIdentity called %d times, want 2", o.identities.createCalled)
+	}
+	if username := o.identities.created[0].Username; !strings.HasPrefix(username, "alice-") {
+		t.Errorf("username = %q, want alice with a suffix", username)
+	}
+}
+
+func TestOIDCCompleteLoginLinksByVerifiedEmail(t *testing.T) {
+	o := newOIDCTest(t, true)
+	existing := &service_models.User{ID: 7, Username: "alice", Email: "alice@example.com"}
+	o.users.users = append(o.users.users, existing)
+	authURL, flow := o.begin(t)
+	state, code, _ := o.provider.authorize(t, authURL, "subject-1", "alice@example.com")
+
+	if _, err := o.service.CompleteLogin(context.Background(), flow, state, code); err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if len(o.identities.created) != 0 {
+		t.Errorf("created %d users, want the existing one linked", len(o.identities.created))
+	}
+	if len(o.identities.identities) != 1 || o.identities.identities[0].UserID != existing.ID {
+		t.Errorf("identity not linked to the existing user: %+v", o.identities.identities)
+	}
+	if len(o.auth.loggedIn) != 1 || o.auth.loggedIn[0] != existing {
+		t.Errorf("logged in %+v, want the existing user", o.auth.loggedIn)
+	}
+}
+
+func TestOIDCCompleteLoginRejectsUnverifiedEmailForLinking(t *testing.T) {
+	o := newOIDCTest(t, true)
+	o.users.users = append(o.users.users, &service_models.User{ID: 7, Username: "alice", Email: "alice@example.com"})
+	authURL, flow := o.begin(t)
+	state, code, claims := o.provider.authorize(t, authURL, "subject-1", "alice@example.com")
+	claims["email_verified"] = false
+
+	_, err := o.service.CompleteLogin(context.Background(), flow, state, code)
+	if !errors.Is(err, repository.ErrOIDCEmailRequired) {
+		t.Fatalf("CompleteLogin = %v, want ErrOIDCEmailRequired", err)
+	}
+	if len(o.identities.identities) != 0 || len(o.auth.loggedIn) != 0 {
+		t.Error("an unverified email was linked or logged in")
+	}
+}
+
+func TestOIDCCompleteLoginRejectsStateMismatch(t *testing.T) {
+	o := newOIDCTest(t, false)
+	authURL, flow := o.begin(t)
+	_, code, _ := o.provider.authorize(t, authURL, "subject-1", "alice@example.com")
+
+	_, err := o.service.CompleteLogin(context.Background(), flow, "another-state", code)
+	if !errors.Is(err, repository.ErrInvalidOIDCState) {
+		t.Fatalf("CompleteLogin = %v, want ErrInvalidOIDCState", err)
+	}
+	if len(o.auth.loggedIn) != 0 {
+		t.Error("logged in despite the state mismatch")
+	}
+}
+
+func TestOIDCCompleteLoginRejectsExpiredFlow(t *testing.T) {
+	o := newOIDCTest(t, false)
+	authURL, flow := o.begin(t)
+	state, code, _ := o.provider.authorize(t, authURL, "subject-1", "alice@example.com")
+
+	opened, err := openOIDCFlow(flow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened.ExpiresAt = time.Now().Add(-time.Second)
+	expired, err := sealOIDCFlow(opened)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = o.service.CompleteLogin(context.Background(), expired, state, code)
+	if !errors.Is(err, repository.ErrInvalidOIDCState) {
+		t.Fatalf("CompleteLogin = %v, want ErrInvalidOIDCState", err)
+	}
+}
+
+func TestOIDCCompleteLoginRejectsTamperedFlow(t *testing.T) {
+	o := newOIDCTest(t, false)
+	authURL, flow := o.begin(t)
+	state, code, _ := o.provider.authorize(t, authURL, "subject-1", "alice@example.com")
+
+	_, err := o.service.CompleteLogin(context.Background(), flow[:len(flow)-2]+"AA", state, code)
+	if !errors.Is(err, repository.ErrInvalidOIDCState) {
+		t.Fatalf("CompleteLogin = %v, want ErrInvalidOIDCState", err)
+	}
+}
+
+func TestOIDCCompleteLoginRejectsNonceMismatch(t *testing.T) {
+	o := newOIDCTest(t, false)
+	authURL, flow := o.begin(t)
+	state, code, claims := o.provider.authorize(t, authURL, "subject-1", "alice@example.com")
+	claims["nonce"] = "another-nonce"
+
+	_, err := o.service.CompleteLogin(context.Background(), flow, state, code)
+	if !errors.Is(err, repository.ErrOIDCLoginFailed) {
+		t.Fatalf("CompleteLogin = %v, want ErrOIDCLoginFailed", err)
+	}
+	if len(o.identities.created) != 0 || len(o.auth.loggedIn) != 0 {
+		t.Error("a user was created or logged in despite the nonce mismatch")
+	}
+}
+
+func TestOIDCCompleteLoginRejectsCodeFromAnotherFlow(t *testing.T) {
+	o := newOIDCTest(t, false)
+	authURL, _ := o.begin(t)
+	_, code, _ := o.provider.authorize(t, authURL, "subject-1", "alice@example.com")
+
+	// A second flow in the same browser has its own state and verifier, so
+	// the code issued for the first one fails the PKCE check.
+	otherURL, otherFlow := o.begin(t)
+	otherState, _, _ := o.provider.authorize(t, otherURL, "subject-2", "bob@example.com")
+
+	_, err := o.service.CompleteLogin(context.Background(), otherFlow, otherState, code)
+	if !errors.Is(err, repository.ErrOIDCLoginFailed) {
+		t.Fatalf("CompleteLogin = %v, want ErrOIDCLoginFailed", err)
+	}
+	if len(o.auth.loggedIn) != 0 {
+		t.Error("logged in with a code from another flow")
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"slices"
+	"strings"
+)
+
+type Role interface {
+	CreateRole(ctx context.Context, role *service_models.Role) (*service_models.Role, error)
+	GetRoleById(ctx context.Context, id int64) (*service_models.Role, error)
+	GetAllRoles(ctx context.Context) ([]*service_models.Role, error)
+	UpdateRole(ctx context.Context, role *service_models.Role) (*service_models.Role, error)
+	DeleteRole(ctx context.Context, id int64) error
+	GetAllPermissions(ctx context.Context) ([]*service_models.Permission, error)
+	GetUserRoles(ctx context.Context, userID int64) (*service_models.UserRoles, error)
+	SetUserRoles(ctx context.Context, userID int64, roles []string) (*service_models.UserRoles, error)
+	GetWithTXT(tx *sql.Tx) Role
+}
+
+type roleService struct {
+	roleRepo repository.Role
+}
+
+func (r *roleService) CreateRole(ctx context.Context, role *service_models.Role) (*service_models.Role, error) {
+	role.Name = normalizeName(role.Name)
+	role.Permissions = normalizeNames(role.Permissions)
+	if err := r.checkPermissions(ctx, role.Permissions); err != nil {
+		return nil, err
+	}
+	return r.roleRepo.CreateRole(ctx, role)
+}
+
+func (r *roleService) GetRoleById(ctx context.Context, id int64) (*service_models.Role, error) {
+	return r.roleRepo.GetRoleById(ctx, id)
+}
+
+func (r *roleService) GetAllRoles(ctx context.Context) ([]*service_models.Role, error) {
+	return r.roleRepo.GetAllRoles(ctx)
+}
+
+// UpdateRole changes a role. Built-in roles keep their name, and the admin
+// role keeps every permission so it can always undo a mistake.
+func (r *roleService) UpdateRole(ctx context.Context, role *service_models.Role) (*service_models.Role, error) {
+	existing, err := r.roleRepo.GetRoleById(ctx, role.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	role.Name = normalizeName(role.Name)
+	role.Permissions = normalizeNames(role.Permissions)
+	if existing.Builtin && role.Name != existing.Name {
+		return nil, repository.ErrBuiltinRole
+	}
+	if existing.Name == service_models.RoleAdmin && !slices.Equal(role.Permissions, existing.Permissions) {
+		return nil, repository.ErrAdminRoleLocked
+	}
+	if err = r.checkPermissions(ctx, role.Permissions); err != nil {
+		return nil, err
+	}
+	return r.roleRepo.UpdateRole(ctx, role)
+}
+
+func (r *roleService) DeleteRole(ctx context.Context, id int64) error {
+	role, err := r.roleRepo.GetRoleById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if role.Builtin {
+		return repository.ErrBuiltinRole
+	}
+	return r.roleRepo.DeleteRole(ctx, id)
+}
+
+func (r *roleService) GetAllPermissions(ctx context.Context) ([]*service_models.Permission, error) {
+	return r.roleRepo.GetAllPermissions(ctx)
+}
+
+func (r *roleService) GetUserRoles(ctx context.Context, userID int64) (*service_models.UserRoles, error) {
+	roles, err := r.roleRepo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &service_models.UserRoles{UserID: userID, Roles: roles}, nil
+}
+
+// SetUserRoles replaces the roles of a user. The last admin cannot lose the
+// admin role; callers run it in a transaction so that check holds. Permissions are resolved on every request, so the change takes
+// effect without logging the user out.
+func (r *roleService) SetUserRoles(ctx context.Context, userID int64, roles []string) (*service_models.UserRoles, error) {
+	roles = normalizeNames(roles)
+	missing, err := r.roleRepo.GetMissingRoles(ctx, roles)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", repository.ErrUnknownRole, strings.Join(missing, ", "))
+	}
+
+	current, err := r.roleRepo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(current, service_models.RoleAdmin) && !slices.Contains(roles, service_models.RoleAdmin) {
+		admins, err := r.roleRepo.CountRoleMembers(ctx, service_models.RoleAdmin)
+		if err != nil {
+			return nil, err
+		}
+		if admins <= 1 {
+			return nil, repository.ErrLastAdmin
+		}
+	}
+
+	if err = r.roleRepo.SetUserRoles(ctx, userID, roles); err != nil {
+		return nil, err
+	}
+	return &service_models.UserRoles{UserID: userID, Roles: roles}, nil
+}
+
+func (r *roleService) checkPermissions(ctx context.Context, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+	missing, err := r.roleRepo.GetMissingPermissions(ctx, permissions)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", repository.ErrUnknownPermission, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (r *roleService) GetWithTXT(tx *sql.Tx) Role {
+	return &roleService{
+		roleRepo: r.roleRepo.GetWithTXT(tx),
+	}
+}
+
+func NewRoleService(roleRepo repository.Role) Role {
+	return &roleService{
+		roleRepo: roleRepo,
+	}
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// normalizeNames lowercases, sorts and deduplicates names.
+func normalizeNames(names []string) []string {
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		normalized = append(normalized, normalizeName(name))
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
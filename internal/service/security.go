@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"github.com/saleh-ghazimoradi/GoJobs/logger"
+)
+
+// recordSecurityEvent writes event to the security log. It goes to the
+// application log as well as the security_events table, so it is not lost
+// when the insert fails.
+func recordSecurityEvent(ctx context.Context, securityEventRepo repository.SecurityEvent, event *service_models.SecurityEvent) {
+	args := []any{"security_event", event.Event, "username", event.Username, "ip", event.IPAddress}
+	if event.UserID != nil {
+		args = append(args, "user_id", *event.UserID)
+	}
+	for key, value := range event.Details {
+		args = append(args, key, value)
+	}
+	logger.Logger.Warn("security event", args...)
+
+	if err := securityEventRepo.CreateSecurityEvent(ctx, event); err != nil {
+		logger.Logger.Error("unable to store security event", "security_event", event.Event, "error", err.Error())
+	}
+}
@@ -0,0 +1,450 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+	"golang.org/x/crypto/bcrypt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Seed interface {
+	Generate(ctx context.Context, opts *service_models.SeedOptions) (*service_models.SeedResult, error)
+	Purge(ctx context.Context) (*service_models.SeedResult, error)
+}
+
+type seedService struct {
+	seedRepo   repository.Seed
+	tagRepo    repository.Tag
+	transactor Transactor
+	roles      []string
+}
+
+// Generate inserts opts.Users users, opts.Companies companies and opts.Jobs
+// jobs, and makes the users who own companies or post jobs recruiters. Rows
+// are generated before each batch's transaction opens, so a retried
+// transaction inserts the same batch and a run with the same seed always
+// produces the same data. Timestamps are relative to the time of the run.
+func (s *seedService) Generate(ctx context.Context, opts *service_models.SeedOptions) (*service_models.SeedResult, error) {
+	if opts.Users < 0 || opts.Companies < 0 || opts.Jobs < 0 || opts.BatchSize <= 0 {
+		return nil, errors.New("counts must not be negative and the batch size must be positive")
+	}
+	if opts.Users == 0 && opts.Companies+opts.Jobs > 0 {
+		return nil, errors.New("companies and jobs need at least one user")
+	}
+
+	exists, err := s.seedRepo.HasSeedData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		// A second run would collide with the usernames and slugs of the first.
+		return nil, repository.ErrSeedDataExists
+	}
+
+	tags, err := s.tagRepo.GetAllTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	password, err := bcrypt.GenerateFromPassword([]byte(service_models.SeedPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &seedGenerator{
+		rng:      rand.New(rand.NewPCG(opts.Seed, seedStream)),
+		now:      time.Now().Truncate(time.Second),
+		password: string(password),
+	}
+	for _, tag := range tags {
+		g.tags = append(g.tags, tag.Name)
+	}
+	slices.Sort(g.tags)
+
+	result := &service_models.SeedResult{}
+
+	userIDs := make([]int64, 0, opts.Users)
+	err = inBatches(opts.Users, opts.BatchSize, func(start, end int) error {
+		users := make([]*service_models.User, 0, end-start)
+		for i := start; i < end; i++ {
+			users = append(users, g.user(i))
+		}
+		err := s.transactor.WithTx(ctx, func(tx *sql.Tx) error {
+			return s.seedRepo.GetWithTXT(tx).InsertUsers(ctx, users, s.roles)
+		})
+		if err != nil {
+			return fmt.Errorf("error inserting users: %w", err)
+		}
+		for _, user := range users {
+			userIDs = append(userIDs, user.ID)
+		}
+		result.Users += int64(len(users))
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	companies := make([]*seedCompany, 0, opts.Companies)
+	err = inBatches(opts.Companies, opts.BatchSize, func(start, end int) error {
+		batch := make([]*seedCompany, 0, end-start)
+		for i := start; i < end; i++ {
+			batch = append(batch, g.company(i, userIDs))
+		}
+		err := s.transactor.WithTx(ctx, func(tx *sql.Tx) error {
+			seedRepo := s.seedRepo.GetWithTXT(tx)
+			records := make([]*service_models.Company, len(batch))
+			for i, company := range batch {
+				records[i] = company.Company
+			}
+			if err := seedRepo.InsertCompanies(ctx, records); err != nil {
+				return err
+			}
+			var members []*service_models.CompanyMember
+			for _, company := range batch {
+				for _, member := range company.members {
+					member.CompanyID = company.ID
+					members = append(members, member)
+				}
+			}
+			return seedRepo.InsertCompanyMembers(ctx, members)
+		})
+		if err != nil {
+			return fmt.Errorf("error inserting companies: %w", err)
+		}
+		companies = append(companies, batch...)
+		result.Companies += int64(len(batch))
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	err = inBatches(opts.Jobs, opts.BatchSize, func(start, end int) error {
+		jobs := make([]*service_models.Job, 0, end-start)
+		for i := start; i < end; i++ {
+			jobs = append(jobs, g.job(userIDs, companies))
+		}
+		err := s.transactor.WithTx(ctx, func(tx *sql.Tx) error {
+			return s.seedRepo.GetWithTXT(tx).InsertJobs(ctx, jobs)
+		})
+		if err != nil {
+			return fmt.Errorf("error inserting jobs: %w", err)
+		}
+		result.Jobs += int64(len(jobs))
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// Like real users, the seeded ones need the recruiter role to post jobs.
+	err = s.transactor.WithTx(ctx, func(tx *sql.Tx) error {
+		return s.seedRepo.GetWithTXT(tx).GrantPosterRole(ctx, service_models.RoleRecruiter)
+	})
+	return result, err
+}
+
+func (s *seedService) Purge(ctx context.Context) (*service_models.SeedResult, error) {
+	var result *service_models.SeedResult
+	err := s.transactor.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		result, err = s.seedRepo.GetWithTXT(tx).PurgeSeedData(ctx)
+		return err
+	})
+	return result, err
+}
+
+// inBatches calls fn with consecutive [start, end) ranges of at most size
+// items covering 0 to n.
+func inBatches(n, size int, fn func(start, end int) error) error {
+	for start := 0; start < n; start += size {
+		if err := fn(start, min(start+size, n)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedStream is the second half of the PCG state, fixed so that the seed
+// option alone decides the generated data.
+const seedStream = 0x676f6a6f6273
+
+type seedGenerator struct {
+	rng      *rand.Rand
+	now      time.Time
+	password string
+	tags     []string
+}
+
+type seedCompany struct {
+	*service_models.Company
+	members []*service_models.CompanyMember
+}
+
+func (g *seedGenerator) user(i int) *service_models.User {
+	first, last := pick(g.rng, seedFirstNames), pick(g.rng, seedLastNames)
+	created := g.past(365 * 24 * time.Hour)
+	// The index keeps usernames and emails unique however the names repeat.
+	handle := fmt.Sprintf("%s.%s%d", strings.ToLower(first), strings.ToLower(last), i+1)
+	return &service_models.User{
+		Username:        "seed_" + strings.ReplaceAll(handle, ".", "_"),
+		Email:           handle + "@" + service_models.SeedDomain,
+		Password:        g.password,
+		EmailVerifiedAt: &created,
+		CreateAt:        created,
+	}
+}
+
+// company makes a company owned by one of the users, with a recruiter half of
+// the time.
+func (g *seedGenerator) company(i int, userIDs []int64) *seedCompany {
+	name := fmt.Sprintf("%s %s", pick(g.rng, seedCompanyPrefixes), pick(g.rng, seedCompanySuffixes))
+	slug, _ := slugify(name)
+	slug = fmt.Sprintf("seed-%s-%d", slug, i+1)
+	website := fmt.Sprintf("https://%s.%s", slug, service_models.SeedDomain)
+	created := g.past(365 * 24 * time.Hour)
+
+	owner := userIDs[i%len(userIDs)]
+	company := &seedCompany{
+		Company: &service_models.Company{
+			Name:    name,
+			Slug:    slug,
+			Website: &website,
+			Description: fmt.Sprintf("%s builds %s for %s. We are a team of %d spread across %d offices.",
+				name, pick(g.rng, seedProducts), pick(g.rng, seedCustomers), 10+g.rng.IntN(990), 1+g.rng.IntN(8)),
+			CreatedAt: created,
+			UpdatedAt: created,
+		},
+		members: []*service_models.CompanyMember{{UserID: owner, Role: service_models.CompanyOwner, CreatedAt: created}},
+	}
+	if len(userIDs) > 1 && g.rng.IntN(2) == 0 {
+		recruiter := userIDs[g.rng.IntN(len(userIDs))]
+		if recruiter != owner {
+			company.members = append(company.members, &service_models.CompanyMember{UserID: recruiter, Role: service_models.CompanyRecruiter, CreatedAt: created})
+		}
+	}
+	return company
+}
+
+// job makes a job posted by a member of a random company, or by a random user
+// under a made-up company name when there are no companies.
+func (g *seedGenerator) job(userIDs []int64, companies []*seedCompany) *service_models.Job {
+	role := pick(g.rng, seedRoles)
+	level := pick(g.rng, seedLevels)
+	location := pick(g.rng, seedLocations)
+
+	job := &service_models.Job{
+		Title:     strings.TrimSpace(level.title + " " + role.title),
+		Location:  location.name,
+		CreatedAt: g.past(90 * 24 * time.Hour),
+	}
+	if len(companies) > 0 {
+		company := companies[g.rng.IntN(len(companies))]
+		job.Company = company.Name
+		job.CompanyID = &company.ID
+		job.UserID = company.members[g.rng.IntN(len(company.members))].UserID
+	} else {
+		job.Company = fmt.Sprintf("%s %s", pick(g.rng, seedCompanyPrefixes), pick(g.rng, seedCompanySuffixes))
+		job.UserID = userIDs[g.rng.IntN(len(userIDs))]
+	}
+	job.Description = g.description(job, role)
+
+	// Yearly salaries are rounded to thousands; monthly and hourly ones are
+	// derived from them for contract roles.
+	yearly := int64(float64(role.salary) * level.factor * location.factor * (0.9 + 0.2*g.rng.Float64()))
+	low, high := yearly/1000*1000, (yearly*(115+int64(g.rng.IntN(25)))/100)/1000*1000
+	period := service_models.SalaryYearly
+	switch n := g.rng.IntN(10); {
+	case n == 0:
+		period = service_models.SalaryHourly
+		low, high = low/2000, high/2000
+	case n == 1:
+		period = service_models.SalaryMonthly
+		low, high = low/12/100*100, high/12/100*100
+	}
+	if g.rng.IntN(10) > 0 {
+		currency := location.currency
+		job.SalaryMin, job.SalaryMax = &low, &high
+		job.SalaryCurrency, job.SalaryPeriod = &currency, &period
+	}
+	if g.rng.IntN(4) == 0 {
+		job.Salary = pick(g.rng, seedSalaryNotes)
+	}
+
+	for _, i := range g.rng.Perm(len(g.tags))[:min(len(g.tags), g.rng.IntN(4))] {
+		job.Tags = append(job.Tags, g.tags[i])
+	}
+
+	// Most jobs are open; the rest cover the other states of the lifecycle.
+	switch n := g.rng.IntN(20); {
+	case n < 2:
+		job.Status = service_models.JobDraft
+	case n < 4:
+		job.Status = service_models.JobClosed
+		published := job.CreatedAt.Add(time.Hour)
+		closed := published.Add(g.between(g.now.Sub(published)))
+		job.PublishedAt, job.ClosedAt = &published, &closed
+	case n < 6:
+		job.Status = service_models.JobExpired
+		published := job.CreatedAt.Add(time.Hour)
+		expires := published.Add(g.between(g.now.Sub(published)))
+		job.PublishedAt, job.ExpiresAt, job.ClosedAt = &published, &expires, &expires
+	default:
+		job.Status = service_models.JobPublished
+		published := job.CreatedAt.Add(time.Hour)
+		job.PublishedAt = &published
+		if g.rng.IntN(2) == 0 {
+			expires := g.now.Add(time.Duration(1+g.rng.IntN(60)) * 24 * time.Hour)
+			job.ExpiresAt = &expires
+		}
+	}
+	return job
+}
+
+func (g *seedGenerator) description(job *service_models.Job, role seedRole) string {
+	remote := "on site in " + job.Location
+	if job.Location == "Remote" {
+		remote = "fully remote"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s is looking for a %s to join the team, %s.\n\n", job.Company, job.Title, remote)
+	fmt.Fprintf(&b, "You will %s and %s.\n\n", pick(g.rng, role.duties), pick(g.rng, seedSharedDuties))
+	b.WriteString("What we are looking for:\n")
+	for _, i := range g.rng.Perm(len(role.skills))[:min(len(role.skills), 2+g.rng.IntN(2))] {
+		fmt.Fprintf(&b, "- %s\n", role.skills[i])
+	}
+	fmt.Fprintf(&b, "- %s\n\n", pick(g.rng, seedSoftSkills))
+	benefits := g.rng.Perm(len(seedBenefits))
+	fmt.Fprintf(&b, "We offer %s and %s.", seedBenefits[benefits[0]], seedBenefits[benefits[1]])
+	return b.String()
+}
+
+// past returns a time up to d before the start of the run.
+func (g *seedGenerator) past(d time.Duration) time.Time {
+	return g.now.Add(-g.between(d))
+}
+
+// between returns a whole number of seconds in [0, d).
+func (g *seedGenerator) between(d time.Duration) time.Duration {
+	if d < time.Second {
+		return 0
+	}
+	return time.Duration(g.rng.Int64N(int64(d/time.Second))) * time.Second
+}
+
+func pick[T any](rng *rand.Rand, items []T) T {
+	return items[rng.IntN(len(items))]
+}
+
+type seedRole struct {
+	title  string
+	salary int64
+	duties []string
+	skills []string
+}
+
+type seedLevel struct {
+	title  string
+	factor float64
+}
+
+type seedLocation struct {
+	name     string
+	currency string
+	factor   float64
+}
+
+var seedFirstNames = []string{"Ava", "Liam", "Noah", "Emma", "Olivia", "Lucas", "Mia", "Amir", "Sara", "Leila", "Reza", "Yuki", "Hana", "Mateo",
+	"Sofia", "Elena", "Jonas", "Nina", "Omar", "Layla", "Arjun", "Priya", "Chen", "Mei", "Tom", "Anna", "Ivan", "Olga", "Kofi", "Amara"}
+
+var seedLastNames = []string{"Smith", "Johnson", "Garcia", "Muller", "Rossi", "Silva", "Kim", "Tanaka", "Ahmadi", "Karimi", "Novak", "Kowalski",
+	"Nielsen", "Jansen", "Dubois", "Moreau", "Lopez", "Patel", "Singh", "Wang", "Li", "Okafor", "Mensah", "Ivanova", "Costa", "Berg"}
+
+var seedCompanyPrefixes = []string{"Blue", "Bright", "North", "Quantum", "Silver", "Atlas", "Nimbus", "Orbit", "Pine", "Cedar", "Harbor",
+	"Vertex", "Lumen", "Summit", "Nova", "Granite", "Copper", "Echo", "Falcon", "Meridian"}
+
+var seedCompanySuffixes = []string{"Labs", "Systems", "Software", "Analytics", "Health", "Logistics", "Payments", "Robotics", "Media",
+	"Energy", "Networks", "Studio", "Cloud", "Works", "Technologies"}
+
+var seedProducts = []string{"payment infrastructure", "logistics software", "developer tools", "data pipelines", "mobile banking apps",
+	"telemedicine platforms", "e-commerce storefronts", "fleet tracking", "learning platforms", "security tooling"}
+
+var seedCustomers = []string{"small businesses", "hospitals", "retailers", "banks", "schools", "manufacturers", "startups",
+	"public agencies", "travel companies", "energy providers"}
+
+var seedLevels = []seedLevel{
+	{"Junior", 0.7}, {"", 1}, {"", 1}, {"Senior", 1.3}, {"Senior", 1.3}, {"Staff", 1.6}, {"Lead", 1.5}, {"Principal", 1.8},
+}
+
+var seedRoles = []seedRole{
+	{"Backend Engineer", 90_000,
+		[]string{"design and build APIs used by thousands of customers", "own services from design to production", "improve the reliability of our platform"},
+		[]string{"Experience with Go, Java or Python", "Solid SQL and PostgreSQL knowledge", "Experience with distributed systems", "Familiarity with Docker and Kubernetes"}},
+	{"Frontend Engineer", 85_000,
+		[]string{"build fast and accessible user interfaces", "work closely with designers on new features", "shape our component library"},
+		[]string{"Strong TypeScript and React skills", "An eye for detail and accessibility", "Experience with testing frontends", "Knowledge of web performance"}},
+	{"Full Stack Developer", 85_000,
+		[]string{"ship features across the whole stack", "build internal tools for our operations team", "turn product ideas into working software"},
+		[]string{"Experience with a modern web framework", "Comfort with both backend and frontend work", "Knowledge of relational databases", "Experience with REST APIs"}},
+	{"DevOps Engineer", 95_000,
+		[]string{"run and automate our cloud infrastructure", "improve our CI/CD pipelines", "keep our systems observable and secure"},
+		[]string{"Experience with AWS, GCP or Azure", "Infrastructure as code with Terraform", "Strong Linux skills", "Experience with Prometheus and Grafana"}},
+	{"Data Engineer", 92_000,
+		[]string{"build and maintain our data pipelines", "model data for analytics and reporting", "make data reliable and easy to find"},
+		[]string{"Strong SQL skills", "Experience with Spark or dbt", "Python programming", "Experience with data warehouses"}},
+	{"Data Scientist", 95_000,
+		[]string{"build models that power product decisions", "run experiments and analyse their results", "turn data into insights for the business"},
+		[]string{"Statistics and machine learning", "Python and its data libraries", "Clear communication of results", "Experience with A/B testing"}},
+	{"Mobile Developer", 88_000,
+		[]string{"build our iOS and Android apps", "improve app performance and stability", "ship new features to millions of users"},
+		[]string{"Experience with Swift or Kotlin", "Knowledge of React Native or Flutter", "Published apps in the stores", "Experience with mobile testing"}},
+	{"Product Manager", 100_000,
+		[]string{"define the roadmap for one of our products", "talk to customers and turn their needs into plans", "work with engineering and design on what to build next"},
+		[]string{"Experience managing software products", "Strong analytical skills", "Excellent written communication", "Experience with agile teams"}},
+	{"Product Designer", 80_000,
+		[]string{"design flows from research to polished UI", "run user research and usability tests", "grow our design system"},
+		[]string{"A strong portfolio", "Expertise in Figma", "Experience with user research", "Understanding of accessibility"}},
+	{"QA Engineer", 70_000,
+		[]string{"build our test automation", "make releases safe and predictable", "find bugs before our customers do"},
+		[]string{"Experience with test automation frameworks", "Knowledge of API testing", "Attention to detail", "Experience with CI pipelines"}},
+	{"Security Engineer", 105_000,
+		[]string{"keep our platform and customer data secure", "review designs and code for security issues", "respond to security incidents"},
+		[]string{"Knowledge of web application security", "Experience with threat modelling", "Familiarity with cloud security", "Scripting in Python or Go"}},
+	{"Engineering Manager", 120_000,
+		[]string{"lead a team of engineers", "hire and grow great people", "balance delivery with technical quality"},
+		[]string{"Experience leading engineering teams", "A background in software development", "Strong coaching skills", "Experience with hiring"}},
+}
+
+var seedLocations = []seedLocation{
+	{"Remote", "USD", 1}, {"Remote", "EUR", 0.85}, {"New York, NY", "USD", 1.4}, {"San Francisco, CA", "USD", 1.5}, {"Austin, TX", "USD", 1.15},
+	{"Toronto, Canada", "CAD", 1.1}, {"London, UK", "GBP", 0.9}, {"Berlin, Germany", "EUR", 0.75}, {"Amsterdam, Netherlands", "EUR", 0.8},
+	{"Paris, France", "EUR", 0.75}, {"Stockholm, Sweden", "SEK", 7.5}, {"Zurich, Switzerland", "CHF", 1.1}, {"Warsaw, Poland", "PLN", 1.6},
+	{"Dubai, UAE", "AED", 3.5}, {"Bangalore, India", "INR", 25}, {"Tokyo, Japan", "JPY", 110}, {"Sydney, Australia", "AUD", 1.5},
+}
+
+var seedSharedDuties = []string{"review code and share knowledge with the team", "take part in planning and retrospectives",
+	"mentor other team members", "help us improve how we work", "join an on-call rotation once a month"}
+
+var seedSoftSkills = []string{"Good written and spoken English", "A collaborative attitude", "Ownership of your work",
+	"Curiosity and a willingness to learn", "Comfort working in a fast-moving team"}
+
+var seedBenefits = []string{"flexible working hours", "a yearly learning budget", "stock options", "30 days of paid leave",
+	"private health insurance", "a home office budget", "parental leave", "a four-day week in summer"}
+
+var seedSalaryNotes = []string{"plus equity", "plus yearly bonus", "depending on experience", "plus relocation package", "negotiable"}
+
+func NewSeedService(seedRepo repository.Seed, tagRepo repository.Tag, transactor Transactor, roles []string) Seed {
+	return &seedService{
+		seedRepo:   seedRepo,
+		tagRepo:    tagRepo,
+		transactor: transactor,
+		roles:      roles,
+	}
+}
@@ -0,0 +1,35 @@
+package service_models
+
+import "time"
+
+// APIKeyPrefix starts every API key, so keys are easy to recognise in
+// Authorization headers and by secret scanners.
+const APIKeyPrefix = "gjk_"
+
+// APIKey is a long-lived credential for integrations. Only Prefix and a hash
+// of the key are stored. Scopes are permission names and cap what the key can
+// do; the owner's current permissions still apply on top of them.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKey is returned once, when the key is created. Key cannot be
+// recovered afterwards.
+type CreatedAPIKey struct {
+	*APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyPayload struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,required,max=100"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
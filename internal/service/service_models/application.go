@@ -0,0 +1,44 @@
+package service_models
+
+import "time"
+
+type ApplicationStage string
+
+const (
+	StageApplied   ApplicationStage = "applied"
+	StageScreening ApplicationStage = "screening"
+	StageInterview ApplicationStage = "interview"
+	StageOffer     ApplicationStage = "offer"
+	StageHired     ApplicationStage = "hired"
+	StageRejected  ApplicationStage = "rejected"
+)
+
+type Application struct {
+	ID          int64            `json:"id"`
+	JobID       int64            `json:"job_id"`
+	UserID      int64            `json:"user_id"`
+	CoverLetter string           `json:"cover_letter"`
+	Resume      *string          `json:"resume"`
+	Stage       ApplicationStage `json:"stage"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+type ApplicationPayload struct {
+	CoverLetter string `json:"cover_letter" validate:"required,max=10000"`
+}
+
+type ApplicationHistory struct {
+	ID            int64             `json:"id"`
+	ApplicationID int64             `json:"application_id"`
+	FromStage     *ApplicationStage `json:"from_stage"`
+	ToStage       ApplicationStage  `json:"to_stage"`
+	ActorID       *int64            `json:"actor_id"`
+	Note          string            `json:"note"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+type ChangeStagePayload struct {
+	Stage ApplicationStage `json:"stage" validate:"required,oneof=applied screening interview offer hired rejected"`
+	Note  string           `json:"note" validate:"max=2000"`
+}
@@ -1,5 +1,7 @@
 package service_models
 
+import "time"
+
 type RegisterAuthPayload struct {
 	Username string `json:"username" validate:"required,max=100"`
 	Password string `json:"password" validate:"required,min=3,max=72"`
@@ -10,3 +12,34 @@ type LoginAuthPayload struct {
 	Username string `json:"username" validate:"required,max=100"`
 	Password string `json:"password" validate:"required,min=3,max=72"`
 }
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresAt is when the access token expires; the refresh token lives longer.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type RefreshTokenPayload struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=256"`
+}
+
+type LogoutPayload struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=256"`
+	// AllDevices also revokes every other session and access token of the user.
+	AllDevices bool `json:"all_devices"`
+}
+
+// RefreshToken is the stored form of a refresh token. Every rotation keeps the
+// family of the token it replaces, so a whole login session can be revoked at once.
+type RefreshToken struct {
+	ID     int64
+	UserID int64
+	Family string
+	// MFA is carried over on rotation so refreshed access tokens keep it.
+	MFA       bool
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
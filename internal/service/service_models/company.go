@@ -0,0 +1,34 @@
+package service_models
+
+import "time"
+
+type CompanyRole string
+
+const (
+	CompanyOwner     CompanyRole = "owner"
+	CompanyRecruiter CompanyRole = "recruiter"
+)
+
+type Company struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name" validate:"required,max=255"`
+	// Slug is derived from Name when left empty.
+	Slug        string    `json:"slug" validate:"max=100"`
+	Website     *string   `json:"website" validate:"omitempty,url,max=255"`
+	Description string    `json:"description" validate:"max=10000"`
+	Logo        *string   `json:"logo" validate:"omitempty,url,max=1024"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CompanyMember struct {
+	CompanyID int64       `json:"company_id"`
+	UserID    int64       `json:"user_id"`
+	Username  string      `json:"username"`
+	Role      CompanyRole `json:"role"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type CompanyMemberPayload struct {
+	Role CompanyRole `json:"role" validate:"required,oneof=owner recruiter"`
+}
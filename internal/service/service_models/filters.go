@@ -0,0 +1,87 @@
+package service_models
+
+type JobFilter struct {
+	Page      int    `validate:"min=1,max=10000000"`
+	PageSize  int    `validate:"min=1,max=100"`
+	Cursor    string `validate:"max=512"`
+	Sort      string `validate:"oneof=created_at title company salary_min salary_max"`
+	Direction string `validate:"oneof=asc desc"`
+	Company   string `validate:"max=255"`
+	Location  string `validate:"max=255"`
+	UserID    int64  `validate:"min=0"`
+	CompanyID int64  `validate:"min=0"`
+	// Tags keeps jobs carrying any (TagMode "any") or all (TagMode "all") of the tags.
+	Tags    []string `validate:"max=20,dive,max=50"`
+	TagMode string   `validate:"oneof=any all"`
+	Status  string   `validate:"omitempty,oneof=draft published closed expired archived"`
+	// SalaryMin keeps jobs that can pay at least this amount, SalaryMax keeps
+	// jobs starting at or below it. Both require Currency.
+	SalaryMin int64  `validate:"min=0"`
+	SalaryMax int64  `validate:"min=0"`
+	Currency  string `validate:"required_with=SalaryMin SalaryMax,omitempty,iso4217"`
+	Period    string `validate:"omitempty,oneof=hourly monthly yearly"`
+	// PublishedOnly restricts the listing to published jobs that have not expired.
+	PublishedOnly bool
+}
+
+type Metadata struct {
+	CurrentPage  int    `json:"current_page,omitempty"`
+	PageSize     int    `json:"page_size,omitempty"`
+	FirstPage    int    `json:"first_page,omitempty"`
+	LastPage     int    `json:"last_page,omitempty"`
+	TotalRecords int    `json:"total_records"`
+	NextCursor   string `json:"next_cursor,omitempty"`
+}
+
+func (f *JobFilter) Limit() int {
+	return f.PageSize
+}
+
+func (f *JobFilter) Offset() int {
+	if f.Cursor != "" {
+		return 0
+	}
+	return (f.Page - 1) * f.PageSize
+}
+
+func CalculateMetadata(totalRecords, page, pageSize int) Metadata {
+	if totalRecords == 0 {
+		return Metadata{}
+	}
+
+	return Metadata{
+		CurrentPage:  page,
+		PageSize:     pageSize,
+		FirstPage:    1,
+		LastPage:     (totalRecords + pageSize - 1) / pageSize,
+		TotalRecords: totalRecords,
+	}
+}
+
+type JobSearchFilter struct {
+	Query    string `validate:"required,max=256"`
+	Page     int    `validate:"min=1,max=10000000"`
+	PageSize int    `validate:"min=1,max=100"`
+}
+
+func (f *JobSearchFilter) Limit() int {
+	return f.PageSize
+}
+
+func (f *JobSearchFilter) Offset() int {
+	return (f.Page - 1) * f.PageSize
+}
+
+type CompanyFilter struct {
+	Name     string `validate:"max=255"`
+	Page     int    `validate:"min=1,max=10000000"`
+	PageSize int    `validate:"min=1,max=100"`
+}
+
+func (f *CompanyFilter) Limit() int {
+	return f.PageSize
+}
+
+func (f *CompanyFilter) Offset() int {
+	return (f.Page - 1) * f.PageSize
+}
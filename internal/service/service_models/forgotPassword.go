@@ -3,3 +3,8 @@ package service_models
 type ForgotPasswordRequest struct {
 	Username string `json:"username" validate:"required"`
 }
+
+type ResetPasswordPayload struct {
+	Token    string `json:"token" validate:"required,max=256"`
+	Password string `json:"password" validate:"required,min=3,max=72"`
+}
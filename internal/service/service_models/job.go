@@ -2,15 +2,61 @@ package service_models
 
 import "time"
 
+type JobStatus string
+
+const (
+	JobDraft     JobStatus = "draft"
+	JobPublished JobStatus = "published"
+	JobClosed    JobStatus = "closed"
+	JobExpired   JobStatus = "expired"
+	JobArchived  JobStatus = "archived"
+)
+
+type SalaryPeriod string
+
+const (
+	SalaryHourly  SalaryPeriod = "hourly"
+	SalaryMonthly SalaryPeriod = "monthly"
+	SalaryYearly  SalaryPeriod = "yearly"
+)
+
 type Job struct {
-	ID          int64     `json:"id"`
-	Title       string    `json:"title" validate:"required"`
-	Description string    `json:"description" validate:"required"`
-	Location    string    `json:"location" validate:"required"`
-	Company     string    `json:"company" validate:"required"`
-	Salary      string    `json:"salary" validate:"required"`
-	CreatedAt   time.Time `json:"created_at"`
-	UserID      int64     `json:"user_id"`
+	ID          int64  `json:"id"`
+	Title       string `json:"title" validate:"required"`
+	Description string `json:"description" validate:"required"`
+	Location    string `json:"location" validate:"required"`
+	// Company is the display name; it is taken from the company record when CompanyID is set.
+	Company   string `json:"company" validate:"required_without=CompanyID,max=255"`
+	CompanyID *int64 `json:"company_id" validate:"omitempty,min=1"`
+	// Salary is free-form text shown alongside the structured range, e.g. "plus equity".
+	Salary         string        `json:"salary" validate:"max=255"`
+	SalaryMin      *int64        `json:"salary_min" validate:"omitempty,min=0"`
+	SalaryMax      *int64        `json:"salary_max" validate:"omitempty,min=0"`
+	SalaryCurrency *string       `json:"salary_currency" validate:"omitempty,iso4217"`
+	SalaryPeriod   *SalaryPeriod `json:"salary_period" validate:"omitempty,oneof=hourly monthly yearly"`
+	// Tags are names of existing tags; nil on update keeps the current tags.
+	Tags      []string   `json:"tags" validate:"max=20,dive,required,max=50"`
+	Status    JobStatus  `json:"status"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	// ClearExpiry removes the expiry on update, where an omitted expires_at
+	// keeps the current one.
+	ClearExpiry bool       `json:"clear_expires_at,omitempty" validate:"excluded_with=ExpiresAt"`
+	PublishedAt *time.Time `json:"published_at"`
+	ClosedAt    *time.Time `json:"closed_at"`
+	ArchivedAt  *time.Time `json:"archived_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UserID      int64      `json:"user_id"`
+}
+
+// IsOpen reports whether the job is published and has not passed its expiry.
+func (j *Job) IsOpen(now time.Time) bool {
+	return j.Status == JobPublished && (j.ExpiresAt == nil || j.ExpiresAt.After(now))
+}
+
+type JobSweepResult struct {
+	Acquired bool
+	Expired  int64
+	Archived int64
 }
 
 type UpdateJobPayload struct {
@@ -23,3 +69,12 @@ type UpdateJobPayload struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UserID      int64     `json:"user_id"`
 }
+
+type JobSearchResult struct {
+	*Job
+	Rank float64 `json:"rank"`
+	// TitleHighlight and Snippet are HTML-escaped text with the matches
+	// wrapped in <mark> tags.
+	TitleHighlight string `json:"title_highlight"`
+	Snippet        string `json:"snippet"`
+}
@@ -0,0 +1,56 @@
+package service_models
+
+import "time"
+
+// LoginResult is either a token pair or, when the account uses two-factor
+// authentication, a challenge to complete with POST /v1/login/mfa.
+type LoginResult struct {
+	*TokenPair
+	MFARequired  bool       `json:"mfa_required,omitempty"`
+	MFAToken     string     `json:"mfa_token,omitempty"`
+	MFAExpiresAt *time.Time `json:"mfa_expires_at,omitempty"`
+}
+
+type MFALoginPayload struct {
+	MFAToken string `json:"mfa_token" validate:"required,max=1024"`
+	// Code is a TOTP code or one of the recovery codes.
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type MFACodePayload struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+type MFAStatus struct {
+	TOTPEnabled            bool `json:"totp_enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+	// RequiredForAdmins reflects the security policy set by admins.
+	RequiredForAdmins bool `json:"required_for_admins"`
+}
+
+type MFAPolicy struct {
+	RequireAdminMFA bool      `json:"require_admin_mfa"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TOTP is the stored TOTP state of a user. Secret is encrypted.
+type TOTP struct {
+	Secret         []byte
+	EnabledAt      *time.Time
+	LastStep       *int64
+	FailedAttempts int
+	LockedUntil    *time.Time
+}
+
+type MFAPolicyPayload struct {
+	RequireAdminMFA *bool `json:"require_admin_mfa" validate:"required"`
+}
@@ -0,0 +1,82 @@
+package service_models
+
+import (
+	"slices"
+	"time"
+)
+
+// Built-in roles created by the migrations.
+const (
+	RoleCandidate = "candidate"
+	RoleRecruiter = "recruiter"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Permissions checked by the API. Roles grant any combination of them.
+const (
+	PermissionJobsWrite          = "jobs:write"
+	PermissionJobsManage         = "jobs:manage"
+	PermissionApplicationsWrite  = "applications:write"
+	PermissionApplicationsManage = "applications:manage"
+	PermissionUsersRead          = "users:read"
+	PermissionUsersWrite         = "users:write"
+	PermissionCompaniesManage    = "companies:manage"
+	PermissionTagsManage         = "tags:manage"
+	PermissionRolesManage        = "roles:manage"
+	PermissionSecurityManage     = "security:manage"
+)
+
+type Role struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	Builtin     bool      `json:"builtin"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type RolePayload struct {
+	Name        string   `json:"name" validate:"required,max=50"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions" validate:"dive,required,max=100"`
+}
+
+type Permission struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type UserRolesPayload struct {
+	Roles []string `json:"roles" validate:"required,dive,required,max=50"`
+}
+
+type UserRoles struct {
+	UserID int64    `json:"user_id"`
+	Roles  []string `json:"roles"`
+}
+
+// Principal is the authenticated caller of a request. APIKeyID is set when
+// the request was authenticated with an API key, whose scopes then cap
+// Permissions.
+type Principal struct {
+	UserID      int64
+	Username    string
+	MFA         bool
+	Permissions []string
+	APIKeyID    *int64
+	Scopes      []string
+}
+
+// AuthState is what the auth middleware needs to know about a user on every
+// request.
+type AuthState struct {
+	TokensValidAfter *time.Time
+	Permissions      []string
+}
+
+func (u *User) HasRole(role string) bool {
+	return slices.Contains(u.Roles, role)
+}
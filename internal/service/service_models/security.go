@@ -0,0 +1,33 @@
+package service_models
+
+import "time"
+
+const (
+	SecurityEventLoginLocked     = "login.locked"
+	SecurityEventLoginThrottled  = "login.ip_throttled"
+	SecurityEventAccountUnlocked = "account.unlocked"
+	SecurityEventPasswordSet     = "password.set_by_admin"
+)
+
+// SecurityEvent is an entry of the security log. UserID is nil for events
+// about usernames that do not exist.
+type SecurityEvent struct {
+	ID        int64          `json:"id"`
+	UserID    *int64         `json:"user_id,omitempty"`
+	Event     string         `json:"event"`
+	Username  string         `json:"username,omitempty"`
+	IPAddress string         `json:"ip_address,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// LoginFailures counts the failed logins of a username and of an IP address
+// within the lockout window.
+type LoginFailures struct {
+	ByUsername int
+	ByIP       int
+}
+
+type UnlockAccountPayload struct {
+	Token string `json:"token" validate:"required,max=128"`
+}
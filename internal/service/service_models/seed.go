@@ -0,0 +1,25 @@
+package service_models
+
+// SeedDomain is the domain of the email addresses of seeded users and the
+// websites of seeded companies. .test is reserved, so the addresses never
+// reach anyone. A purge finds seeded rows by their seeded column, not by it.
+const SeedDomain = "seed.gojobs.test"
+
+// SeedPassword is the password of every seeded user.
+const SeedPassword = "gojobs-seed"
+
+type SeedOptions struct {
+	Users     int
+	Companies int
+	Jobs      int
+	// Seed makes runs reproducible: the same seed generates the same rows.
+	Seed uint64
+	// BatchSize is the number of rows inserted per transaction.
+	BatchSize int
+}
+
+type SeedResult struct {
+	Users     int64
+	Companies int64
+	Jobs      int64
+}
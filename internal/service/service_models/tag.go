@@ -0,0 +1,37 @@
+package service_models
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+type Tag struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name" validate:"required,max=50"`
+	// JobCount is the number of open jobs carrying the tag.
+	JobCount  int64     `json:"job_count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NormalizeTag lowercases a tag name and collapses its whitespace so "Go",
+// " go " and "GO" all refer to the same tag.
+func NormalizeTag(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// NormalizeTags normalizes names and returns them sorted without duplicates or blanks.
+func NormalizeTags(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		tag := NormalizeTag(name)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -9,8 +9,12 @@ type User struct {
 	Email          string    `json:"email"`
 	CreateAt       time.Time `json:"create_at"`
 	UpdateAt       time.Time `json:"update_at"`
-	IsAdmin        bool      `json:"is_admin"`
+	Roles          []string  `json:"roles"`
 	ProfilePicture *string   `json:"profile_picture"`
+	// EmailVerifiedAt is nil until the user follows the link sent to Email.
+	EmailVerifiedAt    *time.Time `json:"email_verified_at"`
+	VerificationSentAt *time.Time `json:"-"`
+	MFAEnabled         bool       `json:"mfa_enabled"`
 }
 
 type UserPayload struct {
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
+	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
+)
+
+type Tag interface {
+	CreateTag(ctx context.Context, tag *service_models.Tag) (*service_models.Tag, error)
+	GetAllTags(ctx context.Context) ([]*service_models.Tag, error)
+	UpdateTag(ctx context.Context, tag *service_models.Tag) (*service_models.Tag, error)
+	DeleteTag(ctx context.Context, id int64) error
+	GetWithTXT(tx *sql.Tx) Tag
+}
+
+type tagService struct {
+	tagRepo repository.Tag
+}
+
+func (t *tagService) CreateTag(ctx context.Context, tag *service_models.Tag) (*service_models.Tag, error) {
+	tag.Name = service_models.NormalizeTag(tag.Name)
+	return t.tagRepo.CreateTag(ctx, tag)
+}
+
+func (t *tagService) GetAllTags(ctx context.Context) ([]*service_models.Tag, error) {
+	return t.tagRepo.GetAllTags(ctx)
+}
+
+func (t *tagService) UpdateTag(ctx context.Context, tag *service_models.Tag) (*service_models.Tag, error) {
+	tag.Name = service_models.NormalizeTag(tag.Name)
+	return t.tagRepo.UpdateTag(ctx, tag)
+}
+
+func (t *tagService) DeleteTag(ctx context.Context, id int64) error {
+	return t.tagRepo.DeleteTag(ctx, id)
+}
+
+func (t *tagService) GetWithTXT(tx *sql.Tx) Tag {
+	return &tagService{
+		tagRepo: t.tagRepo.GetWithTXT(tx),
+	}
+}
+
+func NewTagService(tagRepo repository.Tag) Tag {
+	return &tagService{
+		tagRepo: tagRepo,
+	}
+}
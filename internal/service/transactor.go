@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"math/rand/v2"
+	"time"
+)
+
+// txMaxAttempts bounds how often WithTx runs a unit of work that keeps losing
+// serialization conflicts.
+const txMaxAttempts = 4
+
+// Transactor runs units of work in a database transaction. Bind repositories
+// to the transaction with GetWithTXT inside fn.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error
+}
+
+type transactor struct {
+	db *sql.DB
+}
+
+// WithTx runs fn in a serializable transaction and commits it when fn returns
+// nil. When the transaction fails with a serialization failure or a deadlock
+// it is rolled back and fn runs again, so fn must not have side effects
+// outside the database.
+func (t *transactor) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	var err error
+	for attempt := 1; attempt <= txMaxAttempts; attempt++ {
+		if err = t.run(ctx, fn); err == nil || !isRetryableTxError(err) {
+			return err
+		}
+		if attempt == txMaxAttempts {
+			break
+		}
+
+		// Back off with jitter so competing transactions do not collide again.
+		backoff := time.Duration(attempt) * 10 * time.Millisecond
+		backoff += rand.N(backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+	return fmt.Errorf("transaction failed after %d attempts: %w", txMaxAttempts, err)
+}
+
+func (t *transactor) run(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := t.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// isRetryableTxError reports whether err is a serialization failure or a
+// deadlock, after which the whole transaction can safely run again.
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
+
+func NewTransactor(db *sql.DB) Transactor {
+	return &transactor{
+		db: db,
+	}
+}
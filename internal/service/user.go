@@ -9,11 +9,14 @@ import (
 	"github.com/saleh-ghazimoradi/GoJobs/internal/repository"
 	"github.com/saleh-ghazimoradi/GoJobs/internal/service/service_models"
 	"github.com/saleh-ghazimoradi/GoJobs/utils"
+	"golang.org/x/crypto/bcrypt"
 	"path/filepath"
+	"slices"
 )
 
 type User interface {
 	GetUserById(ctx context.Context, id int64) (*service_models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*service_models.User, error)
 	UpdateUserProfile(ctx context.Context, id int64, username, email string) (*service_models.User, error)
 	UpdateUserProfilePicture(ctx context.Context, id int64, picture string) error
 	GetAllUsers(ctx context.Context) ([]*service_models.User, error)
@@ -23,17 +26,19 @@ type User interface {
 }
 
 type userService struct {
-	userRepo repository.User
-	tx       *sql.Tx
+	userRepo   repository.User
+	roleRepo   repository.Role
+	transactor Transactor
 }
 
 func (u *userService) GetUserById(ctx context.Context, id int64) (*service_models.User, error) {
-	if u.tx != nil {
-		return u.userRepo.GetWithTXT(u.tx).GetUserById(ctx, id)
-	}
 	return u.userRepo.GetUserById(ctx, id)
 }
 
+func (u *userService) GetUserByUsername(ctx context.Context, username string) (*service_models.User, error) {
+	return u.userRepo.GetUserByUsername(ctx, username)
+}
+
 func (u *userService) UpdateUserProfile(ctx context.Context, id int64, username, email string) (*service_models.User, error) {
 	user := &service_models.User{ID: id, Username: username, Email: email}
 	return u.userRepo.UpdateUserProfile(ctx, user)
@@ -47,11 +52,34 @@ func (u *userService) GetAllUsers(ctx context.Context) ([]*service_models.User,
 	return u.userRepo.GetAllUsers(ctx)
 }
 
+// DeleteUser deletes the user in a transaction and removes their profile
+// picture once it has committed, so a failed delete never loses the file. The
+// last admin cannot be deleted; the check runs in the same transaction, so
+// concurrent deletes and role changes cannot both pass it.
 func (u *userService) DeleteUser(ctx context.Context, id int64) error {
-	profilePicture, err := u.userRepo.DeleteUser(ctx, id)
+	var profilePicture string
+	err := u.transactor.WithTx(ctx, func(tx *sql.Tx) error {
+		roleRepo := u.roleRepo.GetWithTXT(tx)
+		roles, err := roleRepo.GetUserRoles(ctx, id)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(roles, service_models.RoleAdmin) {
+			admins, err := roleRepo.CountRoleMembers(ctx, service_models.RoleAdmin)
+			if err != nil {
+				return err
+			}
+			if admins <= 1 {
+				return repository.ErrLastAdmin
+			}
+		}
+
+		profilePicture, err = u.userRepo.GetWithTXT(tx).DeleteUser(ctx, id)
+		return err
+	})
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return repository.ErrRecordNotFound
+		if errors.Is(err, repository.ErrRecordNotFound) || errors.Is(err, repository.ErrLastAdmin) {
+			return err
 		}
 		return fmt.Errorf("delete user: %w", err)
 	}
@@ -66,19 +94,42 @@ func (u *userService) DeleteUser(ctx context.Context, id int64) error {
 	return nil
 }
 
+// ChangePassword checks the current password and stores the new one in one
+// transaction, with the user's row locked in between. Users without a password
+// cannot change it here.
 func (u *userService) ChangePassword(ctx context.Context, id int64, currentPassword, newPassword string) error {
-	return u.userRepo.ChangePassword(ctx, id, currentPassword, newPassword)
+	hashedNewPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("error generating new password hash: %w", err)
+	}
+
+	return u.transactor.WithTx(ctx, func(tx *sql.Tx) error {
+		userRepo := u.userRepo.GetWithTXT(tx)
+
+		hashedPassword, err := userRepo.GetPasswordHashForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if hashedPassword == "" || bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(currentPassword)) != nil {
+			return repository.ErrIncorrectPassword
+		}
+
+		return userRepo.UpdateUserPassword(ctx, &service_models.User{ID: id, Password: string(hashedNewPassword)})
+	})
 }
 
 func (u *userService) GetWithTXT(tx *sql.Tx) User {
 	return &userService{
-		userRepo: u.userRepo.GetWithTXT(tx),
-		tx:       tx,
+		userRepo:   u.userRepo.GetWithTXT(tx),
+		roleRepo:   u.roleRepo.GetWithTXT(tx),
+		transactor: u.transactor,
 	}
 }
 
-func NewUserService(userRepo repository.User) User {
+func NewUserService(userRepo repository.User, roleRepo repository.Role, transactor Transactor) User {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:   userRepo,
+		roleRepo:   roleRepo,
+		transactor: transactor,
 	}
 }
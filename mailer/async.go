@@ -0,0 +1,122 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/logger"
+	"sync"
+	"time"
+)
+
+var (
+	ErrQueueFull   = errors.New("mail queue is full")
+	ErrQueueClosed = errors.New("mail queue is closed")
+)
+
+const maxSendAttempts = 3
+
+// AsyncMailer queues messages and delivers them from a pool of workers, so a
+// slow or unreachable mail server never blocks the caller.
+type AsyncMailer struct {
+	next        Mailer
+	queue       chan Message
+	sendTimeout time.Duration
+	wg          sync.WaitGroup
+	mu          sync.RWMutex
+	closed      bool
+}
+
+// Send queues msg and returns immediately. Delivery errors are logged by the
+// worker; the caller only learns about a full or closed queue.
+func (a *AsyncMailer) Send(ctx context.Context, msg Message) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrQueueClosed
+	}
+
+	select {
+	case a.queue <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Shutdown stops accepting messages and waits until the queued ones are sent
+// or ctx is done.
+func (a *AsyncMailer) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("mail queue not drained: %w", ctx.Err())
+	}
+}
+
+func (a *AsyncMailer) work() {
+	defer a.wg.Done()
+	for msg := range a.queue {
+		a.deliver(msg)
+	}
+}
+
+// deliver retries a failed send with a growing delay before giving up.
+func (a *AsyncMailer) deliver(msg Message) {
+	defer func() {
+		if err := recover(); err != nil {
+			logger.Logger.Error("mail delivery panicked", "to", msg.To, "error", fmt.Sprint(err))
+		}
+	}()
+
+	var err error
+	for attempt := 1; attempt <= maxSendAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), a.sendTimeout)
+		err = a.next.Send(ctx, msg)
+		cancel()
+		if err == nil {
+			return
+		}
+		if attempt < maxSendAttempts {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}
+	logger.Logger.Error("failed to send email", "to", msg.To, "subject", msg.Subject, "error", err.Error())
+}
+
+func NewAsyncMailer(next Mailer, queueSize, workers int, sendTimeout time.Duration) *AsyncMailer {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	if sendTimeout <= 0 {
+		sendTimeout = 30 * time.Second
+	}
+
+	a := &AsyncMailer{
+		next:        next,
+		queue:       make(chan Message, queueSize),
+		sendTimeout: sendTimeout,
+	}
+	a.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go a.work()
+	}
+	return a
+}
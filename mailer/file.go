@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type fileMailer struct {
+	dir    string
+	sender string
+}
+
+// Send writes msg as an .eml file that any mail client can open. It is meant
+// for development, to check how emails render without a mail server.
+func (f *fileMailer) Send(ctx context.Context, msg Message) error {
+	body, err := buildMIME(f.sender, msg)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(f.dir, 0o755); err != nil {
+		return err
+	}
+
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), recipient)
+	return os.WriteFile(filepath.Join(f.dir, name), body, 0o644)
+}
+
+func NewFileMailer(dir, sender string) Mailer {
+	return &fileMailer{
+		dir:    dir,
+		sender: sender,
+	}
+}
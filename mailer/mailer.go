@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"github.com/saleh-ghazimoradi/GoJobs/logger"
+)
+
+// Message is a rendered email. HTMLBody is optional; when it is set the email
+// is sent as multipart/alternative with TextBody as the plain part.
+type Message struct {
+	To       string
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
+
+// Mailer delivers messages to users. Implementations must be safe for
+// concurrent use.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var ErrUnknownTransport = errors.New("unknown mail transport")
+
+type logMailer struct{}
+
+// Send writes the whole message, body included, to the application log. It is
+// meant for development, where no mail server is available.
+func (l *logMailer) Send(ctx context.Context, msg Message) error {
+	logger.Logger.Info("sending email", "to", msg.To, "subject", msg.Subject, "body", msg.TextBody)
+	return nil
+}
+
+func NewLogMailer() Mailer {
+	return &logMailer{}
+}
+
+// New builds the transport selected in the config and wraps it in a queue, so
+// Send never waits for the mail server. Call Shutdown on the result to flush
+// the queue before exiting.
+func New(cfg config.Mailer) (*AsyncMailer, error) {
+	var transport Mailer
+	switch cfg.Transport {
+	case "smtp":
+		transport = NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.Sender)
+	case "file":
+		transport = NewFileMailer(cfg.FileDir, cfg.Sender)
+	case "log", "":
+		transport = NewLogMailer()
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTransport, cfg.Transport)
+	}
+	return NewAsyncMailer(transport, cfg.QueueSize, cfg.Workers, cfg.SendTimeout), nil
+}
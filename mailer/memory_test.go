@@ -0,0 +1,59 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAsyncMailerDeliversTemplatedMessage(t *testing.T) {
+	memory := NewMemoryMailer()
+	async := NewAsyncMailer(memory, 10, 2, time.Second)
+
+	msg, err := NewMessage("alice@example.com", "password_reset.tmpl", "es-MX,es;q=0.9", map[string]any{
+		"Username": "alice",
+		"TTL":      "1h0m0s",
+		"Link":     "https://gojobs.test/reset?token=abc&x=<y>",
+	})
+	if err != nil {
+		t.Fatalf("NewMessage: %v", err)
+	}
+	if err = async.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = async.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	sent := memory.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("got %d messages, want 1", len(sent))
+	}
+	got := sent[0]
+	if got.To != "alice@example.com" {
+		t.Errorf("To = %q, want alice@example.com", got.To)
+	}
+	if got.Subject != "Restablece tu contraseña de GoJobs" {
+		t.Errorf("Subject = %q, want the Spanish subject", got.Subject)
+	}
+	if !strings.Contains(got.TextBody, "Hola alice") || !strings.Contains(got.TextBody, "token=abc&x=<y>") {
+		t.Errorf("TextBody is missing the name or the raw link:\n%s", got.TextBody)
+	}
+	if !strings.Contains(got.HTMLBody, "token=abc&amp;x=%3cy%3e") {
+		t.Errorf("HTMLBody does not escape the link:\n%s", got.HTMLBody)
+	}
+
+	if err = async.Send(context.Background(), msg); !errors.Is(err, ErrQueueClosed) {
+		t.Errorf("Send after Shutdown = %v, want ErrQueueClosed", err)
+	}
+
+	memory.Reset()
+	if len(memory.Sent()) != 0 {
+		t.Error("Reset kept messages")
+	}
+}
@@ -0,0 +1,101 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"golang.org/x/text/language"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Each template file lives in templates/<locale>/ and defines a "subject", a
+// "plainBody" and, optionally, an "htmlBody" block.
+//
+//go:embed templates
+var templateFS embed.FS
+
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+// DefaultLocale is used when none of the requested locales has a translation.
+const DefaultLocale = "en"
+
+var (
+	locales []string
+	matcher language.Matcher
+)
+
+func init() {
+	entries, err := fs.ReadDir(templateFS, "templates")
+	if err != nil {
+		panic(err)
+	}
+
+	tags := []language.Tag{language.Make(DefaultLocale)}
+	locales = []string{DefaultLocale}
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != DefaultLocale {
+			tags = append(tags, language.Make(e.Name()))
+			locales = append(locales, e.Name())
+		}
+	}
+	matcher = language.NewMatcher(tags)
+}
+
+// MatchLocale picks the best supported locale for an Accept-Language style
+// list such as "es-MX,es;q=0.9,en;q=0.8".
+func MatchLocale(preferred ...string) string {
+	tags, _, _ := language.ParseAcceptLanguage(strings.Join(preferred, ","))
+	_, index, _ := matcher.Match(tags...)
+	return locales[index]
+}
+
+// NewMessage renders the named template for the recipient in the best match
+// for locale, falling back to DefaultLocale when it has no translation.
+func NewMessage(to, name, locale string, data any) (Message, error) {
+	locale = MatchLocale(locale)
+	path := fmt.Sprintf("templates/%s/%s", locale, name)
+	if _, err := fs.Stat(templateFS, path); err != nil {
+		path = fmt.Sprintf("templates/%s/%s", DefaultLocale, name)
+		if _, err = fs.Stat(templateFS, path); err != nil {
+			return Message{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+		}
+	}
+
+	textTmpl, err := texttemplate.New("").ParseFS(templateFS, path)
+	if err != nil {
+		return Message{}, err
+	}
+
+	msg := Message{To: to}
+	if msg.Subject, err = executeText(textTmpl, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if msg.TextBody, err = executeText(textTmpl, "plainBody", data); err != nil {
+		return Message{}, err
+	}
+
+	htmlTmpl, err := htmltemplate.New("").ParseFS(templateFS, path)
+	if err != nil {
+		return Message{}, err
+	}
+	if htmlTmpl.Lookup("htmlBody") != nil {
+		var buf bytes.Buffer
+		if err = htmlTmpl.ExecuteTemplate(&buf, "htmlBody", data); err != nil {
+			return Message{}, err
+		}
+		msg.HTMLBody = buf.String()
+	}
+	return msg, nil
+}
+
+func executeText(tmpl *texttemplate.Template, name string, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
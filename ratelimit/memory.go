@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+// MemoryStore keeps buckets in the process. Each replica limits clients on its
+// own, so the effective limit grows with the number of replicas.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func (m *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Capacity()), updated: now}
+		m.buckets[key] = b
+	}
+
+	tokens, result := take(b.tokens, b.updated, now, limit)
+	b.tokens = tokens
+	b.updated = now
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// Sweep drops the buckets that have refilled completely by now, since a new
+// bucket would behave the same, and returns how many it dropped.
+func (m *MemoryStore) Sweep(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dropped := 0
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
@@ -0,0 +1,100 @@
+// Package ratelimit implements token bucket rate limiting. Buckets live in a
+// Store, so replicas can share them by plugging in a shared backend instead of
+// the default in-memory store.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"math"
+	"time"
+)
+
+var ErrUnknownStore = errors.New("unknown rate limit store")
+
+// Limit describes a token bucket that refills Requests tokens every Period and
+// holds at most Burst of them. Burst defaults to Requests when it is zero.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// Capacity is the number of tokens a full bucket holds.
+func (l Limit) Capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// Rate is the number of tokens added to the bucket per second.
+func (l Limit) Rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result is the state of a bucket after a request tried to take a token from
+// it. Reset is the time until the bucket is full again and RetryAfter, set when
+// the request was denied, the time until the next token is available.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. Implementations must be safe for concurrent use.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Sweeper is implemented by stores that must drop idle buckets themselves
+// rather than relying on their backend to expire them.
+type Sweeper interface {
+	Sweep(now time.Time) int
+}
+
+// New builds the store selected in the config.
+func New(cfg config.RateLimit) (Store, error) {
+	switch cfg.Store {
+	case "memory", "":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStore, cfg.Store)
+	}
+}
+
+// take refills a bucket holding tokens, last updated at updated, up to now and
+// tries to take one token from it. It returns the tokens left and the result;
+// stores keeping buckets elsewhere can use it to share the arithmetic.
+func take(tokens float64, updated, now time.Time, limit Limit) (float64, Result) {
+	capacity := float64(limit.Capacity())
+	rate := limit.Rate()
+
+	if elapsed := now.Sub(updated).Seconds(); elapsed > 0 {
+		tokens = math.Min(capacity, tokens+elapsed*rate)
+	}
+
+	result := Result{Limit: limit.Capacity()}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = seconds((capacity - tokens) / rate)
+	return tokens, result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
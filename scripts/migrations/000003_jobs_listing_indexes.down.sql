@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS jobs_user_id_idx;
+DROP INDEX IF EXISTS jobs_company_id_idx;
+DROP INDEX IF EXISTS jobs_title_id_idx;
+DROP INDEX IF EXISTS jobs_created_at_id_idx;
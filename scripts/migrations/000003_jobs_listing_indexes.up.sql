@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS jobs_created_at_id_idx ON jobs (created_at, id);
+CREATE INDEX IF NOT EXISTS jobs_title_id_idx ON jobs (title, id);
+CREATE INDEX IF NOT EXISTS jobs_company_id_idx ON jobs (company, id);
+CREATE INDEX IF NOT EXISTS jobs_user_id_idx ON jobs (user_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS jobs_search_vector_idx;
+ALTER TABLE jobs DROP COLUMN IF EXISTS search_vector;
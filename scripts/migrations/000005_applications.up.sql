@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS applications (
+    id bigserial PRIMARY KEY,
+    job_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    cover_letter TEXT NOT NULL,
+    resume TEXT,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT applications_job_id_user_id_key UNIQUE (job_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS applications_user_id_idx ON applications (user_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS application_history;
+ALTER TABLE applications DROP COLUMN IF EXISTS stage;
@@ -0,0 +1,19 @@
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS stage TEXT NOT NULL DEFAULT 'applied'
+    CHECK (stage IN ('applied', 'screening', 'interview', 'offer', 'hired', 'rejected'));
+
+CREATE TABLE IF NOT EXISTS application_history (
+    id bigserial PRIMARY KEY,
+    application_id bigint NOT NULL,
+    from_stage TEXT,
+    to_stage TEXT NOT NULL,
+    actor_id bigint,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS application_history_application_id_idx ON application_history (application_id, created_at);
+
+INSERT INTO application_history (application_id, to_stage, actor_id, created_at)
+SELECT id, stage, user_id, created_at FROM applications;
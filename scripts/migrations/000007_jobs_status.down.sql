@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS jobs_status_expires_at_idx;
+ALTER TABLE jobs
+    DROP COLUMN IF EXISTS closed_at,
+    DROP COLUMN IF EXISTS published_at,
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,10 @@
+ALTER TABLE jobs
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'draft'
+        CHECK (status IN ('draft', 'published', 'closed', 'expired')),
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP(0) WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS published_at TIMESTAMP(0) WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP(0) WITH TIME ZONE;
+
+UPDATE jobs SET status = 'published', published_at = created_at WHERE published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS jobs_status_expires_at_idx ON jobs (status, expires_at);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS jobs_status_closed_at_idx;
+
+UPDATE jobs SET status = 'closed' WHERE status = 'archived';
+ALTER TABLE jobs DROP COLUMN IF EXISTS archived_at;
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_status_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_status_check
+    CHECK (status IN ('draft', 'published', 'closed', 'expired'));
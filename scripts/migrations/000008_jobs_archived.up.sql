@@ -0,0 +1,9 @@
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_status_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_status_check
+    CHECK (status IN ('draft', 'published', 'closed', 'expired', 'archived'));
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP(0) WITH TIME ZONE;
+
+UPDATE jobs SET closed_at = expires_at WHERE status = 'expired' AND closed_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS jobs_status_closed_at_idx ON jobs (status, closed_at);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS jobs_salary_idx;
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_salary_range_check;
+ALTER TABLE jobs ALTER COLUMN salary DROP DEFAULT;
+ALTER TABLE jobs
+    DROP COLUMN IF EXISTS salary_period,
+    DROP COLUMN IF EXISTS salary_currency,
+    DROP COLUMN IF EXISTS salary_max,
+    DROP COLUMN IF EXISTS salary_min;
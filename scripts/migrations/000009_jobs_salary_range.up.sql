@@ -0,0 +1,54 @@
+ALTER TABLE jobs
+    ADD COLUMN IF NOT EXISTS salary_min BIGINT CHECK (salary_min >= 0),
+    ADD COLUMN IF NOT EXISTS salary_max BIGINT CHECK (salary_max >= 0),
+    ADD COLUMN IF NOT EXISTS salary_currency CHAR(3),
+    ADD COLUMN IF NOT EXISTS salary_period TEXT CHECK (salary_period IN ('hourly', 'monthly', 'yearly'));
+
+ALTER TABLE jobs ALTER COLUMN salary SET DEFAULT '';
+
+CREATE FUNCTION pg_temp.parse_salary_amount(amount TEXT, multiplier TEXT) RETURNS BIGINT AS $$
+    SELECT (regexp_replace(amount, '[.,](\d{3})', '\1', 'g')::numeric * CASE WHEN multiplier = 'k' THEN 1000 ELSE 1 END)::bigint
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Best-effort parse of the free-form salary text, e.g. "80k", "$80,000 - $100,000",
+-- "50.000-70.000 EUR per year" or "25 USD/hour". Rows that cannot be parsed
+-- unambiguously keep only their original text in the salary column.
+WITH parsed AS (
+    SELECT id,
+           regexp_match(lower(salary), '(\d+(?:[.,]\d{3})*(?:\.\d+)?)\s*(k)?(?:\s*(?:-|–|to)\s*\D{0,3}(\d+(?:[.,]\d{3})*(?:\.\d+)?)\s*(k)?)?') AS amounts,
+           CASE
+               WHEN salary ~ '\$' OR salary ~* '\musd\M' THEN 'USD'
+               WHEN salary ~ '€' OR salary ~* '\meur\M' THEN 'EUR'
+               WHEN salary ~ '£' OR salary ~* '\mgbp\M' THEN 'GBP'
+               ELSE upper(substring(salary FROM '(?i)\m(cad|aud|chf|jpy|sek|nok|dkk|pln|inr|try|aed)\M'))
+           END AS currency,
+           CASE
+               WHEN salary ~* '(hour|\mhr\M|/h\M)' THEN 'hourly'
+               WHEN salary ~* '(month|\mmo\M)' THEN 'monthly'
+               WHEN salary ~* '(year|\myr\M|annum|annual|\mpa\M)' THEN 'yearly'
+           END AS period
+    FROM jobs
+    WHERE salary_min IS NULL AND salary_max IS NULL
+), amounts AS (
+    SELECT id,
+           currency,
+           period,
+           pg_temp.parse_salary_amount(amounts[1], coalesce(amounts[2], amounts[4])) AS salary_min,
+           pg_temp.parse_salary_amount(coalesce(amounts[3], amounts[1]), coalesce(amounts[4], amounts[2])) AS salary_max
+    FROM parsed
+    WHERE amounts IS NOT NULL AND currency IS NOT NULL
+)
+UPDATE jobs SET
+    salary_min = amounts.salary_min,
+    salary_max = amounts.salary_max,
+    salary_currency = amounts.currency,
+    salary_period = coalesce(amounts.period, 'yearly')
+FROM amounts
+WHERE jobs.id = amounts.id
+  AND amounts.salary_min <= amounts.salary_max
+  AND (amounts.period IS NOT NULL OR amounts.salary_min >= 10000);
+
+ALTER TABLE jobs ADD CONSTRAINT jobs_salary_range_check
+    CHECK (salary_min IS NULL OR salary_max IS NULL OR salary_min <= salary_max);
+
+CREATE INDEX IF NOT EXISTS jobs_salary_idx ON jobs (salary_currency, salary_min, salary_max);
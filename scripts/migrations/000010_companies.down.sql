@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS jobs_company_id_idx;
+ALTER TABLE jobs DROP COLUMN IF EXISTS company_id;
+DROP TABLE IF EXISTS company_members;
+DROP TABLE IF EXISTS companies;
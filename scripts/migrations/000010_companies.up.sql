@@ -0,0 +1,59 @@
+CREATE TABLE IF NOT EXISTS companies (
+    id bigserial PRIMARY KEY,
+    name TEXT NOT NULL,
+    slug TEXT NOT NULL,
+    website TEXT,
+    description TEXT NOT NULL DEFAULT '',
+    logo TEXT,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT companies_slug_key UNIQUE (slug)
+);
+
+CREATE TABLE IF NOT EXISTS company_members (
+    company_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'recruiter')),
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (company_id, user_id),
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS company_members_user_id_idx ON company_members (user_id);
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS company_id bigint REFERENCES companies(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS jobs_company_id_idx ON jobs (company_id);
+
+-- company_slug folds spelling variants such as "Acme", "ACME Inc." and "acme"
+-- onto the same slug by dropping common legal suffixes and punctuation.
+CREATE FUNCTION pg_temp.company_slug(name TEXT) RETURNS TEXT AS $$
+    SELECT trim(BOTH '-' FROM regexp_replace(
+        regexp_replace(lower(trim(name)), '[\s,]+(inc|ltd|llc|gmbh|corp|corporation|co|company|limited|plc)\.?$', ''),
+        '[^a-z0-9]+', '-', 'g'))
+$$ LANGUAGE sql IMMUTABLE;
+
+-- One company per distinct slug, named after its most common spelling.
+INSERT INTO companies (name, slug, created_at)
+SELECT mode() WITHIN GROUP (ORDER BY company), pg_temp.company_slug(company), min(created_at)
+FROM jobs
+WHERE pg_temp.company_slug(company) <> ''
+GROUP BY pg_temp.company_slug(company)
+ON CONFLICT (slug) DO NOTHING;
+
+UPDATE jobs SET company_id = companies.id, company = companies.name
+FROM companies
+WHERE jobs.company_id IS NULL AND companies.slug = pg_temp.company_slug(jobs.company);
+
+-- Everyone who posted for a company becomes a recruiter of it; the earliest
+-- poster becomes its owner.
+INSERT INTO company_members (company_id, user_id, role)
+SELECT company_id, user_id, CASE WHEN position = 1 THEN 'owner' ELSE 'recruiter' END
+FROM (
+    SELECT company_id, user_id, row_number() OVER (PARTITION BY company_id ORDER BY min(created_at), user_id) AS position
+    FROM jobs
+    WHERE company_id IS NOT NULL
+    GROUP BY company_id, user_id
+) posters
+ON CONFLICT (company_id, user_id) DO NOTHING;
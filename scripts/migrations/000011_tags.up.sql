@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS tags (
+    id bigserial PRIMARY KEY,
+    name TEXT NOT NULL CHECK (name <> '' AND name = lower(name)),
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT tags_name_key UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS job_tags (
+    job_id bigint NOT NULL,
+    tag_id bigint NOT NULL,
+    PRIMARY KEY (job_id, tag_id),
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS job_tags_tag_id_idx ON job_tags (tag_id);
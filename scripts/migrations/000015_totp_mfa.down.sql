@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS security_settings;
+DROP TABLE IF EXISTS recovery_codes;
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS mfa;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS mfa_locked_until,
+    DROP COLUMN IF EXISTS mfa_failed_attempts,
+    DROP COLUMN IF EXISTS totp_last_step,
+    DROP COLUMN IF EXISTS totp_enabled_at,
+    DROP COLUMN IF EXISTS totp_secret;
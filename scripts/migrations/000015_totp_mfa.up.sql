@@ -0,0 +1,27 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS totp_secret BYTEA,
+    ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMP(0) WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS totp_last_step BIGINT,
+    ADD COLUMN IF NOT EXISTS mfa_failed_attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS mfa_locked_until TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS mfa BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    code_hash BYTEA NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT recovery_codes_user_id_code_hash_key UNIQUE (user_id, code_hash)
+);
+
+-- security_settings holds a single row of settings admins change at runtime.
+CREATE TABLE IF NOT EXISTS security_settings (
+    id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+    require_admin_mfa BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO security_settings (id) VALUES (true) ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,94 @@
+CREATE TABLE IF NOT EXISTS roles (
+    id bigserial PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    builtin BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT roles_name_key UNIQUE (name)
+);
+
+-- permissions is a catalog of the names the code checks; it is not edited at runtime.
+CREATE TABLE IF NOT EXISTS permissions (
+    id bigserial PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id bigint NOT NULL,
+    permission_id bigint NOT NULL,
+    PRIMARY KEY (role_id, permission_id),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (permission_id) REFERENCES permissions(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id bigint NOT NULL,
+    role_id bigint NOT NULL,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS user_roles_role_id_idx ON user_roles (role_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('jobs:write', 'Post jobs and manage your own postings'),
+    ('jobs:manage', 'Manage every job posting'),
+    ('applications:write', 'Apply to jobs'),
+    ('applications:manage', 'Review applications to every job'),
+    ('users:read', 'List and view every user'),
+    ('users:write', 'Update and delete every user'),
+    ('companies:manage', 'Manage every company and its members'),
+    ('tags:manage', 'Create, rename and delete tags'),
+    ('roles:manage', 'Manage roles and assign them to users'),
+    ('security:manage', 'Change security settings such as the two-factor policy')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO roles (name, description, builtin) VALUES
+    ('candidate', 'Looks for jobs and applies to them', true),
+    ('recruiter', 'Posts jobs and reviews applications to them', true),
+    ('moderator', 'Keeps job postings, companies and tags in order', true),
+    ('admin', 'Full access', true)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM (VALUES
+    ('candidate', 'applications:write'),
+    ('recruiter', 'jobs:write'),
+    ('recruiter', 'applications:write'),
+    ('moderator', 'jobs:write'),
+    ('moderator', 'jobs:manage'),
+    ('moderator', 'applications:write'),
+    ('moderator', 'users:read'),
+    ('moderator', 'companies:manage'),
+    ('moderator', 'tags:manage')
+) AS grants (role, permission)
+JOIN roles r ON r.name = grants.role
+JOIN permissions p ON p.name = grants.permission
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
+
+-- Every existing user can keep applying. Only those who have posted jobs or
+-- belong to a company keep posting them.
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM users u CROSS JOIN roles r WHERE r.name = 'candidate'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM users u CROSS JOIN roles r
+WHERE r.name = 'recruiter'
+    AND (EXISTS (SELECT 1 FROM jobs j WHERE j.user_id = u.id) OR EXISTS (SELECT 1 FROM company_members cm WHERE cm.user_id = u.id))
+ON CONFLICT DO NOTHING;
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM users u CROSS JOIN roles r WHERE r.name = 'admin' AND u.is_admin
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
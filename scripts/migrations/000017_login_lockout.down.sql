@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS security_events;
+DROP TABLE IF EXISTS account_unlocks;
+DROP TABLE IF EXISTS login_failures;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS login_locked_until,
+    DROP COLUMN IF EXISTS login_lockouts;
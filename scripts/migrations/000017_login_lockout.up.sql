@@ -0,0 +1,43 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS login_lockouts INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS login_locked_until TIMESTAMP WITH TIME ZONE;
+
+-- login_failures keeps the recent failed logins, unknown usernames included,
+-- so attempts can be counted per username and per IP address.
+CREATE TABLE IF NOT EXISTS login_failures (
+    id bigserial PRIMARY KEY,
+    username TEXT NOT NULL,
+    ip_address TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS login_failures_username_idx ON login_failures (username, created_at);
+CREATE INDEX IF NOT EXISTS login_failures_ip_address_idx ON login_failures (ip_address, created_at);
+CREATE INDEX IF NOT EXISTS login_failures_created_at_idx ON login_failures (created_at);
+
+CREATE TABLE IF NOT EXISTS account_unlocks (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL,
+    token_hash BYTEA NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS account_unlocks_user_id_idx ON account_unlocks (user_id);
+
+-- security_events is the audit trail of lockouts and other security relevant
+-- events.
+CREATE TABLE IF NOT EXISTS security_events (
+    id bigserial PRIMARY KEY,
+    user_id bigint,
+    event TEXT NOT NULL,
+    username TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS security_events_user_id_idx ON security_events (user_id, created_at);
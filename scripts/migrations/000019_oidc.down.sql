@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS user_identities;
+
+-- '!' is not a bcrypt hash, so users without a password still cannot log in
+-- with one until they reset it.
+UPDATE users SET password = '!' WHERE password IS NULL;
+ALTER TABLE users ALTER COLUMN password SET NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE jobs DROP COLUMN IF EXISTS seeded;
+ALTER TABLE companies DROP COLUMN IF EXISTS seeded;
+ALTER TABLE users DROP COLUMN IF EXISTS seeded;
@@ -0,0 +1,9 @@
+-- Rows generated by `GoJobs seed` are marked, so `seed --purge` removes them
+-- and nothing a real user created.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS seeded BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS seeded BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS seeded BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS users_seeded_idx ON users (id) WHERE seeded;
+CREATE INDEX IF NOT EXISTS companies_seeded_idx ON companies (id) WHERE seeded;
+CREATE INDEX IF NOT EXISTS jobs_seeded_idx ON jobs (id) WHERE seeded;
@@ -0,0 +1,169 @@
+// Package migrations embeds the SQL migrations and applies them with
+// golang-migrate. Versions are tracked in the schema_migrations table, the
+// same one the migrate CLI uses, so databases migrated either way stay
+// compatible.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/saleh-ghazimoradi/GoJobs/logger"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Migration is one embedded migration and whether it has been applied.
+type Migration struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// Status is the schema version of a database and the known migrations.
+type Status struct {
+	Version    uint
+	Dirty      bool
+	Migrations []Migration
+}
+
+// Migrator applies the embedded migrations to a database. Every operation
+// holds a Postgres advisory lock, so instances starting together or a
+// concurrent `GoJobs migrate` wait for each other instead of racing.
+type Migrator struct {
+	m *migrate.Migrate
+}
+
+// New returns a Migrator that runs on a dedicated connection from db. Close
+// releases the connection but leaves db open.
+func New(ctx context.Context, db *sql.DB) (*Migrator, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	source, err := iofs.New(files, ".")
+	if err != nil {
+		driver.Close()
+		return nil, err
+	}
+	m, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		driver.Close()
+		return nil, err
+	}
+	m.Log = migrateLogger{}
+	return &Migrator{m: m}, nil
+}
+
+// Up applies all pending migrations, or at most n when n is positive. It
+// reports whether anything was applied.
+func (mg *Migrator) Up(n int) (bool, error) {
+	var err error
+	if n > 0 {
+		err = mg.m.Steps(n)
+	} else {
+		err = mg.m.Up()
+	}
+	return changed(err)
+}
+
+// Down rolls back the last n migrations. It reports whether anything was
+// rolled back.
+func (mg *Migrator) Down(n int) (bool, error) {
+	if n <= 0 {
+		return false, errors.New("the number of migrations to roll back must be positive")
+	}
+	return changed(mg.m.Steps(-n))
+}
+
+// Force sets the schema version without running migrations and clears the
+// dirty flag, to recover after a migration failed halfway.
+func (mg *Migrator) Force(version int) error {
+	return mg.m.Force(version)
+}
+
+// Status returns the schema version and every embedded migration.
+func (mg *Migrator) Status() (*Status, error) {
+	status := &Status{}
+	version, dirty, err := mg.m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+	case err != nil:
+		return nil, err
+	default:
+		status.Version, status.Dirty = version, dirty
+	}
+
+	status.Migrations, err = embedded()
+	if err != nil {
+		return nil, err
+	}
+	for i := range status.Migrations {
+		status.Migrations[i].Applied = status.Migrations[i].Version <= status.Version
+	}
+	return status, nil
+}
+
+func (mg *Migrator) Close() error {
+	sourceErr, dbErr := mg.m.Close()
+	return errors.Join(sourceErr, dbErr)
+}
+
+// embedded lists the embedded migrations by version.
+func embedded() ([]Migration, error) {
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		prefix, rest, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q: %w", name, err)
+		}
+		migrations = append(migrations, Migration{Version: uint(version), Name: strings.TrimSuffix(rest, ".up.sql")})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// changed turns migrate.ErrNoChange into a false result.
+func changed(err error) (bool, error) {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...any) {
+	logger.Logger.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (migrateLogger) Verbose() bool {
+	return false
+}
@@ -0,0 +1,387 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/saleh-ghazimoradi/GoJobs/config"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmRS256 = "RS256"
+	AlgorithmHS256 = "HS256"
+
+	// keyCreatedHeader is the PEM header holding the creation time of a key.
+	keyCreatedHeader = "Created"
+)
+
+var (
+	ErrNoSigningKey            = errors.New("no JWT signing key: generate one with `GoJobs keys generate`")
+	ErrSigningKeyNotFound      = errors.New("JWT_SIGNING_KEY_ID does not match a key")
+	ErrUnknownSigningKey       = errors.New("token is signed with an unknown key")
+	ErrUnsupportedKeyAlgorithm = errors.New("unsupported key algorithm, use EdDSA or RS256")
+)
+
+// SigningKey is a private key that signs access tokens. ID is the RFC 7638
+// thumbprint of its public key and is sent as the kid header.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+	Path      string
+	signer    crypto.Signer
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+	if k.Algorithm == AlgorithmRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+// KeySet holds the keys loaded from config.JWT.KeysDir.
+type KeySet struct {
+	Keys    []*SigningKey
+	Signing *SigningKey
+	secret  []byte
+}
+
+// JSONWebKey is the public half of a signing key as published in the JWKS.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+var jwtKeys atomic.Pointer[KeySet]
+
+// LoadJWTKeys reads the key directory and makes it the key set used to sign
+// and verify tokens. It is safe to call while tokens are being issued, which
+// is how rotated keys are picked up.
+func LoadJWTKeys() error {
+	cfg := config.AppConfig.JWT
+
+	keys, err := ReadSigningKeys(cfg.KeysDir)
+	if err != nil {
+		return err
+	}
+	keys = slices.DeleteFunc(keys, func(key *SigningKey) bool {
+		return !slices.Contains(cfg.Algorithms, key.Algorithm)
+	})
+
+	set := &KeySet{Keys: keys}
+	if slices.Contains(cfg.Algorithms, AlgorithmHS256) && cfg.SecretKEY != "" {
+		set.secret = []byte(cfg.SecretKEY)
+	}
+
+	set.Signing, err = selectSigningKey(keys, cfg.SigningKeyID, cfg.ActivationDelay, time.Now())
+	if err != nil && !(errors.Is(err, ErrNoSigningKey) && set.secret != nil) {
+		return err
+	}
+
+	jwtKeys.Store(set)
+	return nil
+}
+
+// CurrentKeySet returns the key set loaded by LoadJWTKeys, or nil.
+func CurrentKeySet() *KeySet {
+	return jwtKeys.Load()
+}
+
+// SigningKeyStatus describes what a key in keys is used for at now: "signing",
+// "pending" while it waits for its activation delay, or "verify-only".
+func SigningKeyStatus(keys []*SigningKey, key *SigningKey, now time.Time) string {
+	cfg := config.AppConfig.JWT
+	signing, _ := selectSigningKey(keys, cfg.SigningKeyID, cfg.ActivationDelay, now)
+	switch {
+	case signing == key:
+		return "signing"
+	case signing != nil && key.CreatedAt.After(signing.CreatedAt):
+		return "pending"
+	default:
+		return "verify-only"
+	}
+}
+
+// selectSigningKey picks the newest key that has been published for at least
+// delay, or the oldest key when none has, so that a fresh install can sign
+// straight away. keys must be sorted oldest first.
+func selectSigningKey(keys []*SigningKey, pinned string, delay time.Duration, now time.Time) (*SigningKey, error) {
+	if pinned != "" {
+		for _, key := range keys {
+			if key.ID == pinned {
+				return key, nil
+			}
+		}
+		return nil, ErrSigningKeyNotFound
+	}
+	if len(keys) == 0 {
+		return nil, ErrNoSigningKey
+	}
+
+	signing := keys[0]
+	for _, key := range keys[1:] {
+		if !key.CreatedAt.Add(delay).After(now) {
+			signing = key
+		}
+	}
+	return signing, nil
+}
+
+// RetiredSigningKeys returns the keys that can be deleted at now: keys that
+// stopped signing longer ago than any token they signed can live. keys must be
+// sorted oldest first.
+func RetiredSigningKeys(keys []*SigningKey, now time.Time) []*SigningKey {
+	cfg := config.AppConfig.JWT
+	if cfg.SigningKeyID != "" {
+		return nil
+	}
+	maxTokenTTL := max(cfg.AccessTokenTTL, config.AppConfig.MFA.ChallengeTTL)
+
+	var retired []*SigningKey
+	for i := 0; i+1 < len(keys); i++ {
+		// A key signs until its successor is activated.
+		supersededAt := keys[i+1].CreatedAt.Add(cfg.ActivationDelay)
+		if supersededAt.Add(maxTokenTTL).After(now) {
+			break
+		}
+		retired = append(retired, keys[i])
+	}
+	return retired
+}
+
+// ReadSigningKeys reads every *.pem key in dir, oldest first. A missing
+// directory holds no keys.
+func ReadSigningKeys(dir string) ([]*SigningKey, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*SigningKey, 0, len(paths))
+	for _, path := range paths {
+		key, err := readSigningKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+func readSigningKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, errors.New("not a PKCS #8 PEM private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKeyAlgorithm
+	}
+
+	key, err := newSigningKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	key.Path = path
+	if created, ok := block.Headers[keyCreatedHeader]; ok {
+		if key.CreatedAt, err = time.Parse(time.RFC3339, created); err != nil {
+			return nil, err
+		}
+	} else {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		key.CreatedAt = info.ModTime()
+	}
+	return key, nil
+}
+
+// GenerateSigningKey creates a new EdDSA (Ed25519) or RS256 key. bits is only
+// used for RS256.
+func GenerateSigningKey(algorithm string, bits int) (*SigningKey, error) {
+	var (
+		signer crypto.Signer
+		err    error
+	)
+	switch algorithm {
+	case AlgorithmEdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	case AlgorithmRS256:
+		if bits < 2048 {
+			return nil, errors.New("RSA keys must have at least 2048 bits")
+		}
+		signer, err = rsa.GenerateKey(rand.Reader, bits)
+	default:
+		return nil, ErrUnsupportedKeyAlgorithm
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := newSigningKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	key.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	return key, nil
+}
+
+// WriteSigningKey stores key as <kid>.pem in dir, readable only by the owner.
+func WriteSigningKey(dir string, key *SigningKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.signer)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, key.ID+".pem")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	block := &pem.Block{
+		Type:    "PRIVATE KEY",
+		Headers: map[string]string{keyCreatedHeader: key.CreatedAt.Format(time.RFC3339)},
+		Bytes:   der,
+	}
+	if err = pem.Encode(file, block); err != nil {
+		file.Close()
+		return err
+	}
+	key.Path = path
+	return file.Close()
+}
+
+func newSigningKey(signer crypto.Signer) (*SigningKey, error) {
+	key := &SigningKey{signer: signer}
+	switch signer.(type) {
+	case ed25519.PrivateKey:
+		key.Algorithm = AlgorithmEdDSA
+	case *rsa.PrivateKey:
+		key.Algorithm = AlgorithmRS256
+	default:
+		return nil, ErrUnsupportedKeyAlgorithm
+	}
+
+	jwk := key.PublicJWK()
+	// RFC 7638 thumbprint: the required members in lexicographic order.
+	var members map[string]string
+	if jwk.KeyType == "OKP" {
+		members = map[string]string{"crv": jwk.Curve, "kty": jwk.KeyType, "x": jwk.X}
+	} else {
+		members = map[string]string{"e": jwk.E, "kty": jwk.KeyType, "n": jwk.N}
+	}
+	canonical, err := json.Marshal(members)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(canonical)
+	key.ID = base64.RawURLEncoding.EncodeToString(sum[:])
+	return key, nil
+}
+
+// PublicJWK returns the public key in JWK form, without a kid when the ID has
+// not been computed yet.
+func (k *SigningKey) PublicJWK() JSONWebKey {
+	jwk := JSONWebKey{KeyID: k.ID, Use: "sig", Algorithm: k.Algorithm}
+	switch public := k.signer.Public().(type) {
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	}
+	return jwk
+}
+
+// JWKS returns the public keys of the current key set. The HS256 secret is
+// never published.
+func JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	if keySet := CurrentKeySet(); keySet != nil {
+		for _, key := range keySet.Keys {
+			set.Keys = append(set.Keys, key.PublicJWK())
+		}
+	}
+	return set
+}
+
+// verificationKey is the jwt.Keyfunc of the key set. The key is chosen by kid
+// and must match the algorithm in the header, so a public key can never be
+// used as an HMAC secret.
+func (s *KeySet) verificationKey(token *jwt.Token) (interface{}, error) {
+	alg := token.Method.Alg()
+	kid, _ := token.Header["kid"].(string)
+
+	if kid == "" {
+		if alg == AlgorithmHS256 && s.secret != nil {
+			return s.secret, nil
+		}
+		return nil, ErrUnknownSigningKey
+	}
+	for _, key := range s.Keys {
+		if key.ID == kid {
+			if key.Algorithm != alg {
+				return nil, ErrUnknownSigningKey
+			}
+			return key.signer.Public(), nil
+		}
+	}
+	return nil, ErrUnknownSigningKey
+}
+
+func (s *KeySet) sign(claims jwt.Claims) (string, error) {
+	if s.Signing == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(s.secret)
+	}
+
+	token := jwt.NewWithClaims(s.Signing.method(), claims)
+	token.Header["kid"] = s.Signing.ID
+	return token.SignedString(s.Signing.signer)
+}
@@ -1,41 +1,123 @@
 package utils
 
 import (
-	"github.com/dgrijalva/jwt-go"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/saleh-ghazimoradi/GoJobs/config"
 	"time"
 )
 
+// mfaChallengePurpose marks tokens that only prove the password step of a
+// two-factor login. They are never accepted as access tokens.
+const mfaChallengePurpose = "mfa_challenge"
+
+var (
+	ErrWrongTokenPurpose = errors.New("token cannot be used for this purpose")
+	ErrKeysNotLoaded     = errors.New("JWT keys are not loaded")
+)
+
+func init() {
+	// iat is compared with tokens_valid_after, which Postgres stores in
+	// microseconds. Whole seconds cannot tell a token issued right after a
+	// revocation from one issued right before it.
+	jwt.TimePrecision = time.Microsecond
+}
+
 type Claims struct {
 	Username string `json:"username"`
 	UserID   int64  `json:"userid"`
-	IsAdmin  bool   `json:"is_admin"`
-	jwt.StandardClaims
+	// MFA is set when the session was started with a second factor.
+	MFA     bool   `json:"mfa,omitempty"`
+	Purpose string `json:"purpose,omitempty"`
+	jwt.RegisteredClaims
 }
 
-func GenerateToken(username string, userID int64, isAdmin bool) (string, error) {
-	expirationTime := time.Now().Add(10 * time.Hour)
+func GenerateToken(username string, userID int64, mfa bool) (string, error) {
+	now := time.Now()
 	claims := &Claims{
-		Username: username,
-		UserID:   userID,
-		IsAdmin:  isAdmin,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
-		},
+		Username:         username,
+		UserID:           userID,
+		MFA:              mfa,
+		RegisteredClaims: registeredClaims(now, now.Add(config.AppConfig.JWT.AccessTokenTTL)),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return signClaims(claims)
+}
+
+// GenerateMFAChallenge returns a token that can only be exchanged for an
+// access token together with a valid second factor.
+func GenerateMFAChallenge(userID int64, expiresAt time.Time) (string, error) {
+	claims := &Claims{
+		UserID:           userID,
+		Purpose:          mfaChallengePurpose,
+		RegisteredClaims: registeredClaims(time.Now(), expiresAt),
+	}
 
-	return token.SignedString([]byte(config.AppConfig.JWT.SecretKEY))
+	return signClaims(claims)
 }
 
 func ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, ErrWrongTokenPurpose
+	}
+	return claims, nil
+}
+
+func ValidateMFAChallenge(tokenString string) (*Claims, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != mfaChallengePurpose {
+		return nil, ErrWrongTokenPurpose
+	}
+	return claims, nil
+}
+
+func registeredClaims(issuedAt, expiresAt time.Time) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Issuer:    config.AppConfig.JWT.Issuer,
+		Audience:  jwt.ClaimStrings{config.AppConfig.JWT.Audience},
+		IssuedAt:  jwt.NewNumericDate(issuedAt),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+}
+
+func signClaims(claims *Claims) (string, error) {
+	keySet := CurrentKeySet()
+	if keySet == nil {
+		return "", ErrKeysNotLoaded
+	}
+	return keySet.sign(claims)
+}
+
+// parseClaims verifies the signature with the key named by kid and requires
+// an allowed algorithm, our issuer and audience, and exp and iat claims.
+func parseClaims(tokenString string) (*Claims, error) {
+	keySet := CurrentKeySet()
+	if keySet == nil {
+		return nil, ErrKeysNotLoaded
+	}
+
+	cfg := config.AppConfig.JWT
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(cfg.Algorithms),
+		jwt.WithIssuer(cfg.Issuer),
+		jwt.WithAudience(cfg.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.AppConfig.JWT.SecretKEY), nil
-	})
-	if err != nil || !token.Valid {
+	if _, err := parser.ParseWithClaims(tokenString, claims, keySet.verificationKey); err != nil {
 		return nil, err
 	}
+	if claims.IssuedAt == nil {
+		return nil, jwt.ErrTokenRequiredClaimMissing
+	}
 	return claims, nil
 }
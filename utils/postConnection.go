@@ -35,3 +35,32 @@ func PostConnection() (*sql.DB, error) {
 
 	return db, nil
 }
+
+// ReplicaConnection opens the read replica pool, or returns nil when no
+// replica is configured. An unreachable replica is not an error: reads go to
+// the primary until it becomes healthy.
+func ReplicaConnection() (*sql.DB, error) {
+	replicaURI := config.AppConfig.DBConfig.ReplicaSource
+	if replicaURI == "" {
+		return nil, nil
+	}
+
+	logger.Logger.Info("Connecting to the Postgres read replica")
+
+	db, err := sql.Open("postgres", replicaURI)
+	if err != nil {
+		return nil, fmt.Errorf("error opening Postgres replica connection: %w", err)
+	}
+
+	db.SetMaxOpenConns(config.AppConfig.DBConfig.MaxOpenConns)
+	db.SetMaxIdleConns(config.AppConfig.DBConfig.MaxIdleConns)
+	db.SetConnMaxLifetime(config.AppConfig.DBConfig.MaxIdleTime)
+	ctx, cancel := context.WithTimeout(context.Background(), config.AppConfig.DBConfig.Timeout)
+	defer cancel()
+
+	if err = db.PingContext(ctx); err != nil {
+		logger.Logger.Warn("Postgres read replica is unreachable, reading from the primary", "error", err.Error())
+	}
+
+	return db, nil
+}